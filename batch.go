@@ -0,0 +1,143 @@
+package triage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/tj/go-tea"
+)
+
+// batchConcurrency is the maximum number of batch commands in flight.
+var batchConcurrency = 4
+
+// Batch is the progress of a command applied to many notifications.
+type Batch struct {
+	// ID is incremented for each batch, to ignore the steps of previous batches.
+	ID int
+
+	// Label is the action label, for example "Marking as read".
+	Label string
+
+	// Pending is the commands not yet started.
+	Pending []tea.Cmd
+
+	// Total is the number of commands.
+	Total int
+
+	// Done is the number of commands completed.
+	Done int
+
+	// Failed is the number of commands which failed.
+	Failed int
+}
+
+// Running returns true if the batch has commands in flight.
+func (b Batch) Running() bool {
+	return b.Done < b.Total
+}
+
+// startBatch starts a batch of commands, running at most batchConcurrency at a
+// time. The commands not yet started by a running batch are carried over.
+func startBatch(m Model, label string, cmds []tea.Cmd) (Model, tea.Cmd) {
+	cmds = append(cmds, m.Batch.Pending...)
+	n := min(len(cmds), batchConcurrency)
+
+	m.Batch = Batch{
+		ID:      m.Batch.ID + 1,
+		Label:   label,
+		Pending: cmds[n:],
+		Total:   len(cmds),
+	}
+
+	var started []tea.Cmd
+	for _, cmd := range cmds[:n] {
+		started = append(started, batchStep(m.Batch.ID, cmd))
+	}
+
+	return m, tea.Batch(started...)
+}
+
+// nextBatchStep returns the next pending batch command, if any.
+func nextBatchStep(m Model) (Model, tea.Cmd) {
+	if len(m.Batch.Pending) == 0 {
+		return m, nil
+	}
+	cmd := m.Batch.Pending[0]
+	m.Batch.Pending = m.Batch.Pending[1:]
+	return m, batchStep(m.Batch.ID, cmd)
+}
+
+// batchStep returns a command which wraps the msg of cmd in a BatchStepped msg.
+func batchStep(id int, cmd tea.Cmd) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		return BatchStepped{
+			ID:  id,
+			Msg: cmd(ctx),
+			Cmd: cmd,
+		}
+	}
+}
+
+// retryBatchStep returns a command which runs a batch command again after the delay.
+func retryBatchStep(id int, cmd tea.Cmd, attempt int, delay time.Duration) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		if sleep(ctx, delay) != nil {
+			return nil
+		}
+
+		return BatchStepped{
+			ID:      id,
+			Msg:     cmd(ctx),
+			Cmd:     cmd,
+			Attempt: attempt,
+		}
+	}
+}
+
+// batchSummary returns a summary of a completed batch.
+func batchSummary(b Batch) string {
+	if b.Failed == 0 {
+		return fmt.Sprintf("%s %d notifications completed", b.Label, b.Total)
+	}
+	return fmt.Sprintf("%s %d notifications completed, %d failed (press e for details)", b.Label, b.Total, b.Failed)
+}
+
+// checkedNotifications returns the checked notifications.
+func checkedNotifications(m Model) (checked []*github.Notification) {
+	for _, n := range m.Notifications {
+		if m.Checked[notificationKey(n)] {
+			checked = append(checked, n)
+		}
+	}
+	return
+}
+
+// toggleChecked toggles the notifications, checking all of them
+// unless they are all checked already.
+func toggleChecked(m Model, notifications []*github.Notification) Model {
+	all := true
+	for _, n := range notifications {
+		if !m.Checked[notificationKey(n)] {
+			all = false
+			break
+		}
+	}
+
+	checked := make(map[string]bool)
+	for key := range m.Checked {
+		checked[key] = true
+	}
+
+	for _, n := range notifications {
+		if all {
+			delete(checked, notificationKey(n))
+		} else {
+			checked[notificationKey(n)] = true
+		}
+	}
+
+	m.Checked = checked
+	return m
+}
@@ -0,0 +1,303 @@
+package triage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+)
+
+// cacheHeader is the header set on responses served from the cache without
+// revalidation, to "hit" in the cache first mode, or "offline" when the API
+// is unreachable.
+const cacheHeader = "X-From-Cache"
+
+// cacheMaxAge is the duration after which unused entries are evicted.
+var cacheMaxAge = 30 * 24 * time.Hour
+
+// cacheMaxSize is the size of the cache in bytes, beyond which the least
+// recently used entries are evicted.
+var cacheMaxSize int64 = 100 << 20
+
+// Cache is an on-disk cache of API responses, keyed by URL.
+type Cache struct {
+	dir string
+}
+
+// cacheEntry is a cached response.
+type cacheEntry struct {
+	URL    string      `json:"url"`
+	ETag   string      `json:"etag"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// NewCache returns a cache in the given directory, which is created when
+// missing, and pruned of entries which are unused or over the size limit.
+func NewCache(dir string) (*Cache, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cache{dir: dir}
+
+	err = c.prune(cacheMaxAge, cacheMaxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// prune removes the entries unused for longer than maxAge, and the least
+// recently used entries which exceed maxSize in total.
+func (c *Cache) prune(maxAge time.Duration, maxSize int64) error {
+	files, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	// most recently used first
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().After(files[j].ModTime())
+	})
+
+	var size int64
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+
+		size += f.Size()
+		if size > maxSize || time.Since(f.ModTime()) > maxAge {
+			os.Remove(filepath.Join(c.dir, f.Name()))
+		}
+	}
+
+	return nil
+}
+
+// CacheDir returns the cache directory of the account, within the user's
+// cache directory, for example "~/.cache/triage/work".
+func CacheDir(account string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	if account == "" {
+		account = "default"
+	}
+
+	return filepath.Join(dir, "triage", account), nil
+}
+
+// get returns the cached response of a request, if any, marking it as
+// recently used so it is pruned last.
+func (c *Cache) get(req *http.Request) (*cacheEntry, bool) {
+	path := c.path(req)
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var e cacheEntry
+	err = json.Unmarshal(b, &e)
+	if err != nil {
+		return nil, false
+	}
+
+	now := time.Now()
+	os.Chtimes(path, now, now)
+
+	return &e, true
+}
+
+// put caches the response of a request, replacing the file atomically
+// so concurrent readers never see a partial entry.
+func (c *Cache) put(req *http.Request, e *cacheEntry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(c.dir, "entry")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(b)
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), c.path(req))
+}
+
+// path returns the path of a request's entry. The accept header is
+// part of the key, as it changes the representation returned.
+func (c *Cache) path(req *http.Request) string {
+	h := sha256.Sum256([]byte(req.URL.String() + "\n" + req.Header.Get("Accept")))
+	return filepath.Join(c.dir, hex.EncodeToString(h[:])+".json")
+}
+
+// cacheFirstKey is the context key of the cache first mode.
+type cacheFirstKey struct{}
+
+// cacheFirst returns a context in which cached responses are used
+// without revalidation, unless the context is refreshing.
+func cacheFirst(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(cacheFirstKey{}).(bool); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, cacheFirstKey{}, true)
+}
+
+// refreshing returns a context in which cached responses are always revalidated.
+func refreshing(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheFirstKey{}, false)
+}
+
+// fromCache returns true if the response was served from the cache in the cache first mode.
+func fromCache(res *github.Response) bool {
+	return res != nil && res.Response != nil && res.Header.Get(cacheHeader) == "hit"
+}
+
+// servedOffline returns true if the response was served from the cache as the API is unreachable.
+func servedOffline(res *github.Response) bool {
+	return res != nil && res.Response != nil && res.Header.Get(cacheHeader) == "offline"
+}
+
+// CacheTransport is an http.RoundTripper which caches GET responses on disk,
+// revalidating them with their ETag, so unchanged resources are served from
+// the cache without counting against the rate limit. In the cache first mode
+// cached responses are returned without a request, and when the API is
+// unreachable they are returned in place of the error.
+type CacheTransport struct {
+	// Base is the underlying transport.
+	Base http.RoundTripper
+
+	// Cache is the response cache, or nil to disable caching.
+	Cache *Cache
+}
+
+// RoundTrip implementation.
+func (t *CacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// requests which are conditional already, such as notification polling, are left alone
+	if t.Cache == nil || req.Method != http.MethodGet || conditional(req) {
+		return t.Base.RoundTrip(req)
+	}
+
+	e, ok := t.Cache.get(req)
+
+	// cache first
+	if first, _ := req.Context().Value(cacheFirstKey{}).(bool); ok && first {
+		header := e.Header.Clone()
+		header.Set(cacheHeader, "hit")
+		return e.response(req, header), nil
+	}
+
+	// revalidate
+	r := req
+	if ok && e.ETag != "" {
+		r = req.Clone(req.Context())
+		r.Header.Set("If-None-Match", e.ETag)
+	}
+
+	res, err := t.Base.RoundTrip(r)
+
+	// offline, fall back on the cached response
+	if err != nil && ok && isOffline(err) {
+		header := e.Header.Clone()
+		header.Set(cacheHeader, "offline")
+		return e.response(req, header), nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// unchanged, with the fresh headers such as the rate limit
+	if ok && res.StatusCode == http.StatusNotModified {
+		res.Body.Close()
+		header := e.Header.Clone()
+		for k, v := range res.Header {
+			header[k] = v
+		}
+		return e.response(req, header), nil
+	}
+
+	if res.StatusCode != http.StatusOK || res.Header.Get("ETag") == "" {
+		return res, nil
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	// the cache is best-effort, so failing to write it is ignored
+	t.Cache.put(req, &cacheEntry{
+		URL:    req.URL.String(),
+		ETag:   res.Header.Get("ETag"),
+		Header: cacheableHeader(res.Header),
+		Body:   body,
+	})
+
+	return res, nil
+}
+
+// response returns the entry as a response to the request.
+func (e *cacheEntry) response(req *http.Request, header http.Header) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// conditional returns true if the request has conditional headers.
+func conditional(req *http.Request) bool {
+	return req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != ""
+}
+
+// cacheableHeader returns the header without the rate limit, which
+// would be stale when the response is served from the cache.
+func cacheableHeader(header http.Header) http.Header {
+	h := make(http.Header)
+	for k, v := range header {
+		if strings.HasPrefix(k, "X-Ratelimit-") {
+			continue
+		}
+		h[k] = v
+	}
+	return h
+}
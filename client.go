@@ -2,10 +2,218 @@ package triage
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
 
 	"github.com/google/go-github/v28/github"
+	"golang.org/x/oauth2"
 )
 
+// NewClient returns a GitHub client authenticated with token, using
+// the host's URL for GitHub Enterprise Server when present, and the
+// rate limit transport of the client. Responses are cached in cache
+// unless it is nil.
+func NewClient(ctx context.Context, token string, host Host, retry Retry, cache *Cache) (*github.Client, *RateLimitTransport, error) {
+	// custom certificate authority
+	if host.CABundle != "" {
+		pool, err := certPool(host.CABundle)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: transport})
+	}
+
+	httpClient := oauth2.NewClient(ctx, oauth2.StaticTokenSource(
+		&oauth2.Token{
+			AccessToken: token,
+		},
+	))
+
+	// rate limiting and retries
+	rate := &RateLimitTransport{
+		Base: &RetryTransport{
+			Base:   httpClient.Transport,
+			Policy: retry,
+		},
+	}
+	httpClient.Transport = &CacheTransport{
+		Base:  rate,
+		Cache: cache,
+	}
+
+	// github.com
+	if !host.Enterprise() {
+		return github.NewClient(httpClient), rate, nil
+	}
+
+	// enterprise, accepting the host alone as the url
+	base := host.URL
+	if !strings.Contains(base, "/api/") {
+		base = host.WebURL() + "api/v3/"
+	}
+
+	upload := host.UploadURL
+	if upload == "" {
+		upload = host.WebURL() + "api/uploads/"
+	}
+
+	client, err := github.NewEnterpriseClient(base, upload, httpClient)
+	return client, rate, err
+}
+
+// Enterprise returns true if the host is a GitHub Enterprise Server.
+func (h Host) Enterprise() bool {
+	return h.URL != "" && strings.TrimSuffix(h.URL, "/") != "https://api.github.com"
+}
+
+// WebURL returns the web URL of the host, for example "https://github.example.com/".
+func (h Host) WebURL() string {
+	if !h.Enterprise() {
+		return "https://github.com/"
+	}
+
+	u := strings.TrimSuffix(h.URL, "/")
+	u = strings.TrimSuffix(u, "/api/v3")
+	return u + "/"
+}
+
+// certPool returns the system certificate pool with the bundle's certificates added.
+func certPool(path string) (*x509.CertPool, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading ca bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("ca bundle %q contains no certificates", path)
+	}
+
+	return pool, nil
+}
+
+// Clients is a set of GitHub clients, one per account, which routes
+// notifications to the client of the account they were fetched with.
+type Clients struct {
+	names   []string
+	clients map[string]*github.Client
+	rates   map[string]*RateLimitTransport
+
+	mu      sync.Mutex
+	threads map[string]string
+}
+
+// NewClients returns a new set of clients.
+func NewClients() *Clients {
+	return &Clients{
+		clients: make(map[string]*github.Client),
+		rates:   make(map[string]*RateLimitTransport),
+		threads: make(map[string]string),
+	}
+}
+
+// Add a client for the named account, and its rate limit transport when present.
+func (c *Clients) Add(name string, client *github.Client, rate *RateLimitTransport) {
+	c.names = append(c.names, name)
+	c.clients[name] = client
+	if rate != nil {
+		c.rates[name] = rate
+	}
+}
+
+// Names returns the account names, in the order they were added.
+func (c *Clients) Names() []string {
+	return c.names
+}
+
+// Client returns the client of the named account.
+func (c *Clients) Client(name string) *github.Client {
+	return c.clients[name]
+}
+
+// Register the notifications as belonging to the named account.
+func (c *Clients) Register(name string, notifications []*github.Notification) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, n := range notifications {
+		c.threads[n.GetURL()] = name
+	}
+}
+
+// Account returns the name of the notification's account.
+func (c *Clients) Account(n *github.Notification) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	name, ok := c.threads[n.GetURL()]
+	return name, ok
+}
+
+// For returns the client of the notification's account, or nil.
+func (c *Clients) For(n *github.Notification) *github.Client {
+	name, ok := c.Account(n)
+	if !ok {
+		return nil
+	}
+	return c.clients[name]
+}
+
+// Rate returns the rate limit of the account with the least remaining quota.
+func (c *Clients) Rate() (rate github.Rate, ok bool) {
+	for _, name := range c.names {
+		t, tracked := c.rates[name]
+		if !tracked {
+			continue
+		}
+
+		r := t.Rate()
+		if r.Limit == 0 {
+			continue
+		}
+
+		if !ok || r.Remaining < rate.Remaining {
+			rate = r
+			ok = true
+		}
+	}
+	return
+}
+
+// clientFor returns the client of the notification's account, or the default client.
+func clientFor(ctx context.Context, n *github.Notification) *github.Client {
+	if clients, ok := ClientsFromContext(ctx); ok && n != nil {
+		if gh := clients.For(n); gh != nil {
+			return gh
+		}
+	}
+	return MustClientFromContext(ctx)
+}
+
+// clientsKey is a private context key.
+type clientsKey struct{}
+
+// NewClientsContext returns a new context with clients.
+func NewClientsContext(ctx context.Context, v *Clients) context.Context {
+	return context.WithValue(ctx, clientsKey{}, v)
+}
+
+// ClientsFromContext returns clients from context.
+func ClientsFromContext(ctx context.Context) (*Clients, bool) {
+	v, ok := ctx.Value(clientsKey{}).(*Clients)
+	return v, ok
+}
+
 // clientKey is a private context key.
 type clientKey struct{}
 
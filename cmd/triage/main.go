@@ -2,14 +2,17 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/google/go-github/v28/github"
 	"github.com/tj/go-config"
 	"github.com/tj/go-tea"
-	"golang.org/x/oauth2"
 
 	"github.com/tj/triage"
 )
@@ -39,37 +42,118 @@ var defaultPriorities = []triage.Priority{
 }
 
 func main() {
-	ctx := context.Background()
-
-	// require GITHUB_TOKEN
-	token := os.Getenv("GITHUB_TOKEN")
-	if token == "" {
-		fmt.Fprintf(os.Stderr, "\n  The \033[1mGITHUB_TOKEN\033[m environment variable is required.\n")
-		fmt.Fprintf(os.Stderr, "\n  You can generate a personal access token at https://github.com/settings/tokens,\n  then add it to your shell profile, .envrc, or simply `export GITHUB_TOKEN=xxxxxxxx`.\n\n")
-		os.Exit(1)
-	}
+	diagnose := flag.Bool("diagnose", false, "Print the token sources tried and the token scopes")
+	flag.Parse()
 
-	// http client
-	httpClient := oauth2.NewClient(ctx, oauth2.StaticTokenSource(
-		&oauth2.Token{
-			AccessToken: token,
-		},
-	))
-	ctx = triage.NewClientContext(ctx, github.NewClient(httpClient))
+	ctx := context.Background()
 
 	// load config
 	var c triage.Config
-	err := config.LoadHome(".triage.json", &c)
+	err := config.LoadHome(triage.ConfigPath, &c)
 	if err != nil {
 		log.Fatalf("error loading config: %s", err)
 	}
 	ctx = triage.NewConfigContext(ctx, &c)
 
+	// retry defaults
+	if c.Retry.Attempts == 0 {
+		c.Retry.Attempts = 3
+	}
+
+	if c.Retry.Timeout == 0 {
+		c.Retry.Timeout = triage.Duration(time.Second * 10)
+	}
+
+	// enterprise
+	if url := os.Getenv("GITHUB_API_URL"); url != "" {
+		c.GitHub.URL = url
+	}
+
+	// accounts
+	accounts := c.Accounts
+	if len(accounts) == 0 {
+		accounts = []triage.Account{{
+			TokenCommand: c.TokenCommand,
+			Host:         c.GitHub,
+		}}
+	}
+
+	// github clients
+	clients := triage.NewClients()
+	for _, a := range accounts {
+		if len(accounts) > 1 && a.Name == "" {
+			log.Fatalf("error: accounts must be named")
+		}
+
+		if clients.Client(a.Name) != nil {
+			log.Fatalf("error: account %q is defined more than once", a.Name)
+		}
+
+		// discover the token
+		var attempts []triage.Credential
+		if a.Token == "" {
+			attempts = triage.DiscoverToken(ctx, a.Host, a.TokenCommand, len(c.Accounts) == 0)
+			a.Token = attempts[len(attempts)-1].Token
+		}
+
+		if a.Token == "" {
+			printAttempts(a, attempts)
+			fmt.Fprintf(os.Stderr, "\n  You can generate a personal access token at %ssettings/tokens,\n  then add it to your shell profile, .envrc, or simply `export GITHUB_TOKEN=xxxxxxxx`.\n\n", a.Host.WebURL())
+			os.Exit(1)
+		}
+
+		cache, err := newCache(a.Name)
+		if err != nil {
+			log.Printf("warning: responses will not be cached: %s", err)
+		}
+
+		client, rate, err := triage.NewClient(ctx, a.Token, a.Host, c.Retry, cache)
+		if err != nil {
+			log.Fatalf("error creating github client for account %q: %s", a.Name, err)
+		}
+
+		if *diagnose {
+			printAttempts(a, attempts)
+			printScopes(ctx, client)
+		}
+
+		clients.Add(a.Name, client, rate)
+	}
+
+	if *diagnose {
+		fmt.Fprintf(os.Stderr, "\n")
+		return
+	}
+
+	ctx = triage.NewClientsContext(ctx, clients)
+	ctx = triage.NewClientContext(ctx, clients.Client(accounts[0].Name))
+
+	// outbox of changes made while offline
+	home, err := os.UserHomeDir()
+	if err != nil {
+		log.Fatalf("error locating home directory: %s", err)
+	}
+	ctx = triage.NewOutboxContext(ctx, triage.NewOutbox(filepath.Join(home, triage.OutboxPath)))
+
 	// defaults
 	if c.Priorities == nil {
 		c.Priorities = defaultPriorities
 	}
 
+	if c.MaxPages == 0 {
+		c.MaxPages = 10
+	}
+
+	if c.Filter == nil {
+		c.Filter = &triage.Filter{
+			ExcludeTypes: []string{"Release"},
+		}
+	}
+
+	if c.Sort == "" {
+		c.Sort = triage.SortUpdated
+	}
+
 	// start program
 	program := tea.NewProgram(triage.Init, triage.Update, triage.View)
 	err = program.Start(ctx)
@@ -80,6 +164,60 @@ func main() {
 	clear()
 }
 
+// printAttempts prints the token sources tried for an account.
+func printAttempts(a triage.Account, attempts []triage.Credential) {
+	name := a.Host.Hostname()
+	if a.Name != "" {
+		name = fmt.Sprintf("%s (%s)", a.Name, name)
+	}
+
+	fmt.Fprintf(os.Stderr, "\n  Token for \033[1m%s\033[m:\n\n", name)
+
+	if len(attempts) == 0 {
+		fmt.Fprintf(os.Stderr, "    \033[32m✔\033[m .triage.json\n")
+		return
+	}
+
+	for _, c := range attempts {
+		if c.Err != nil {
+			fmt.Fprintf(os.Stderr, "    \033[31m✘\033[m %s: %s\n", c.Source, c.Err)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "    \033[32m✔\033[m %s\n", c.Source)
+	}
+}
+
+// printScopes prints the scopes of the client's token versus those required.
+func printScopes(ctx context.Context, client *github.Client) {
+	scopes, err := triage.TokenScopes(ctx, client)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\n    Scopes: %s\n", err)
+		return
+	}
+
+	if scopes == nil {
+		fmt.Fprintf(os.Stderr, "\n    Scopes: not reported, fine-grained tokens need notifications, issues, and pull request access\n")
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "\n    Scopes: %s\n", strings.Join(scopes, ", "))
+
+	if missing := triage.MissingScopes(scopes); len(missing) > 0 {
+		fmt.Fprintf(os.Stderr, "    Missing: \033[31m%s\033[m\n", strings.Join(missing, ", "))
+	} else {
+		fmt.Fprintf(os.Stderr, "    Missing: none\n")
+	}
+}
+
+// newCache returns the response cache of the account.
+func newCache(account string) (*triage.Cache, error) {
+	dir, err := triage.CacheDir(account)
+	if err != nil {
+		return nil, err
+	}
+	return triage.NewCache(dir)
+}
+
 // clear the screen.
 func clear() {
 	fmt.Printf("\033[2J\033[3J\033[1;1H")
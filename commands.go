@@ -2,10 +2,16 @@ package triage
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -19,7 +25,7 @@ import (
 func GetDimensions(ctx context.Context) tea.Msg {
 	w, h, err := terminfo.GetStdoutDimensions()
 	if err != nil {
-		return err
+		return fail(OpGetDimensions, nil, fmt.Errorf("getting terminal dimensions: %w", err))
 	}
 
 	// pty may be allocated with 0x0,
@@ -34,128 +40,654 @@ func GetDimensions(ctx context.Context) tea.Msg {
 	return GotDimensions{w, h}
 }
 
-// LoadNotifications loads the notifications.
+// LoadNotifications loads the first page of notifications.
 func LoadNotifications(ctx context.Context) tea.Msg {
-	gh := MustClientFromContext(ctx)
+	return LoadNotificationsPage(1, mustClients(ctx).Names())(ctx)
+}
 
-	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
-	defer cancel()
+// LoadNotificationsPage loads a page of notifications from each of the accounts.
+// Accounts which fail are reported with the notifications of the others, unless
+// they all fail.
+func LoadNotificationsPage(page int, accounts []string) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		clients := mustClients(ctx)
 
-	// TODO: pagination
-	options := &github.NotificationListOptions{
-		ListOptions: github.ListOptions{
-			PerPage: 100,
-		},
+		ctx, cancel := withTimeout(ctx, len(accounts))
+		defer cancel()
+
+		options := &github.NotificationListOptions{
+			ListOptions: github.ListOptions{
+				Page:    page,
+				PerPage: 100,
+			},
+		}
+
+		msg := NotificationsPageLoaded{
+			Page:         page,
+			LastModified: make(map[string]string),
+		}
+
+		for _, name := range accounts {
+			gh := clients.Client(name)
+
+			// fetch
+			notifications, res, err := gh.Activity.ListNotifications(ctx, options)
+			if err != nil {
+				msg.Failed = append(msg.Failed, fail(OpLoadNotifications, nil, fmt.Errorf("fetching %snotifications page %d: %w", accountPrefix(name), page, err)))
+				continue
+			}
+
+			clients.Register(name, notifications)
+			msg.Notifications = append(msg.Notifications, notifications...)
+			msg.Offline = msg.Offline || servedOffline(res)
+			msg.LastModified[name] = res.Header.Get("Last-Modified")
+			if res.NextPage != 0 {
+				msg.Next = append(msg.Next, name)
+			}
+			if interval := pollInterval(res); interval > msg.PollInterval {
+				msg.PollInterval = interval
+			}
+		}
+
+		if len(msg.Failed) == len(accounts) && len(accounts) > 0 {
+			return msg.Failed[0]
+		}
+
+		return msg
+	}
+}
+
+// PollNotifications waits for the poll interval and then fetches the first page
+// of notifications from each account, using If-Modified-Since so that unchanged
+// results are free. The next interval is the longest requested by the accounts.
+func PollNotifications(id int, lastModified map[string]string, interval time.Duration) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		clients := mustClients(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+
+		ctx, cancel := withTimeout(ctx, len(clients.Names()))
+		defer cancel()
+
+		msg := NotificationsPolled{
+			ID:           id,
+			LastModified: make(map[string]string),
+		}
+
+		for _, name := range clients.Names() {
+			notifications, modified, next, err := pollAccount(ctx, clients.Client(name), lastModified[name])
+			msg.LastModified[name] = modified
+
+			if err != nil {
+				msg.LastModified[name] = lastModified[name]
+				msg.Err = fmt.Errorf("polling %snotifications: %w", accountPrefix(name), err)
+				msg.Offline = msg.Offline || isOffline(err)
+				continue
+			}
+
+			if next > msg.PollInterval {
+				msg.PollInterval = next
+			}
+
+			clients.Register(name, notifications)
+			msg.Notifications = append(msg.Notifications, notifications...)
+		}
+
+		// every account failed, keep polling at the same rate
+		if msg.PollInterval == 0 {
+			msg.PollInterval = interval
+		}
+
+		return msg
+	}
+}
+
+// pollAccount fetches the first page of an account's notifications modified since
+// lastModified, returning no notifications when they are unchanged, along with
+// the poll interval requested by the X-Poll-Interval header.
+func pollAccount(ctx context.Context, gh *github.Client, lastModified string) ([]*github.Notification, string, time.Duration, error) {
+	req, err := gh.NewRequest("GET", "notifications?per_page=100", nil)
+	if err != nil {
+		return nil, lastModified, 0, err
+	}
+
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
 	}
 
-	var filtered []*github.Notification
+	var notifications []*github.Notification
+	res, err := gh.Do(ctx, req, &notifications)
+
+	// not modified
+	if res != nil && res.StatusCode == http.StatusNotModified {
+		return nil, lastModified, pollInterval(res), nil
+	}
 
-	// fetch
-	notifications, _, err := gh.Activity.ListNotifications(ctx, options)
 	if err != nil {
-		return fmt.Errorf("fetching notifications: %w", err)
+		return nil, lastModified, 0, err
 	}
 
-	// filter
-	for _, n := range notifications {
-		// ignore releases
-		if n.GetSubject().GetType() == "Release" {
-			continue
+	return notifications, res.Header.Get("Last-Modified"), pollInterval(res), nil
+}
+
+// ExpireToast expires the error toast after a delay.
+func ExpireToast(id int) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Second * 5):
+			return ToastExpired{id}
 		}
+	}
+}
 
-		filtered = append(filtered, n)
+// ExpireNotice expires the notice after a delay.
+func ExpireNotice(id int) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Second * 5):
+			return NoticeExpired{id}
+		}
 	}
+}
 
-	return NotificationsLoaded{filtered}
+// ExpireHighlights expires the highlighting of new notifications after a delay.
+func ExpireHighlights(id int) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Second * 5):
+			return HighlightsExpired{id}
+		}
+	}
 }
 
-// LoadNotification loads a notification's issue, labels, and comments.
+// LoadNotification loads a notification's issue, labels, and comments,
+// as well as the pull request details for pull request notifications.
 func LoadNotification(n *github.Notification) tea.Cmd {
+	if isPullRequest(n) {
+		return tea.Batch(
+			LoadNotificationIssue(n),
+			LoadNotificationPullRequest(n),
+		)
+	}
 	return LoadNotificationIssue(n)
 }
 
-// LoadNotificationIssue loads a notification's issue.
+// LoadNotificationIssue loads a notification's issue, from the cache when present.
 func LoadNotificationIssue(n *github.Notification) tea.Cmd {
 	return func(ctx context.Context) tea.Msg {
-		ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+		ctx, cancel := withTimeout(cacheFirst(ctx), 1)
+		defer cancel()
+
+		issue, stateReason, cached, err := getIssue(ctx, n)
+		if err != nil {
+			return fail(OpLoadIssue, n, fmt.Errorf("fetching issue: %w", err))
+		}
+
+		return NotificationIssueLoaded{
+			Notification: n,
+			Issue:        issue,
+			StateReason:  stateReason,
+			Cached:       cached,
+		}
+	}
+}
+
+// LoadNotificationPullRequest loads a notification's pull request.
+func LoadNotificationPullRequest(n *github.Notification) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		ctx, cancel := withTimeout(ctx, 1)
+		defer cancel()
+
+		pr, err := getPullRequest(ctx, n)
+		if err != nil {
+			return fail(OpLoadPullRequest, n, fmt.Errorf("fetching pull request: %w", err))
+		}
+
+		return NotificationPullRequestLoaded{
+			Notification: n,
+			PullRequest:  pr,
+		}
+	}
+}
+
+// LoadPullRequestReviews loads a pull request's reviews.
+func LoadPullRequestReviews(n *github.Notification, pr *github.PullRequest) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		gh := clientFor(ctx, n)
+
+		ctx, cancel := withTimeout(ctx, 1)
+		defer cancel()
+
+		owner, repo := ownerRepo(n)
+		reviews, _, err := gh.PullRequests.ListReviews(ctx, owner, repo, pr.GetNumber(), &github.ListOptions{
+			PerPage: 100,
+		})
+
+		if err != nil {
+			return fail(OpLoadReviews, n, fmt.Errorf("fetching pull request reviews: %w", err))
+		}
+
+		return NotificationReviewsLoaded{
+			Notification: n,
+			Reviews:      reviews,
+		}
+	}
+}
+
+// LoadPullRequestChecks loads a pull request's check runs and commit statuses.
+func LoadPullRequestChecks(n *github.Notification, pr *github.PullRequest) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		gh := clientFor(ctx, n)
+
+		ctx, cancel := withTimeout(ctx, 1)
+		defer cancel()
+
+		owner, repo := ownerRepo(n)
+		ref := pr.GetHead().GetSHA()
+
+		checks, _, err := gh.Checks.ListCheckRunsForRef(ctx, owner, repo, ref, &github.ListCheckRunsOptions{
+			ListOptions: github.ListOptions{
+				PerPage: 100,
+			},
+		})
+
+		if err != nil {
+			return fail(OpLoadChecks, n, fmt.Errorf("fetching check runs: %w", err))
+		}
+
+		status, _, err := gh.Repositories.GetCombinedStatus(ctx, owner, repo, ref, &github.ListOptions{
+			PerPage: 100,
+		})
+
+		if err != nil {
+			return fail(OpLoadChecks, n, fmt.Errorf("fetching commit status: %w", err))
+		}
+
+		return NotificationChecksLoaded{
+			Notification: n,
+			CheckRuns:    checks.CheckRuns,
+			Status:       status,
+		}
+	}
+}
+
+// LoadPullRequestFiles loads all of a pull request's changed files.
+func LoadPullRequestFiles(n *github.Notification, pr *github.PullRequest) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		gh := clientFor(ctx, n)
+
+		ctx, cancel := withTimeout(ctx, 4)
+		defer cancel()
+
+		owner, repo := ownerRepo(n)
+		options := &github.ListOptions{
+			PerPage: 100,
+		}
+
+		var files []*github.CommitFile
+		for {
+			page, res, err := gh.PullRequests.ListFiles(ctx, owner, repo, pr.GetNumber(), options)
+			if err != nil {
+				return fail(OpLoadDiff, n, fmt.Errorf("fetching pull request files: %w", err))
+			}
+
+			files = append(files, page...)
+
+			if res.NextPage == 0 {
+				break
+			}
+
+			options.Page = res.NextPage
+		}
+
+		return PullRequestFilesLoaded{
+			Notification: n,
+			Files:        files,
+		}
+	}
+}
+
+// LoadNotificationsIssues loads the issues of many notifications, used for sorting,
+// at most batchConcurrency at a time. Issues which fail to load are sorted without,
+// until their notification is updated, and the first failure is reported.
+func LoadNotificationsIssues(notifications []*github.Notification) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		ctx, cancel := withTimeout(ctx, len(notifications)/batchConcurrency+1)
 		defer cancel()
 
-		issue, err := getIssue(ctx, n)
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		var err error
+		var errors int
+		var failed *github.Notification
+		issues := make(map[string]*github.Issue)
+		sem := make(chan struct{}, batchConcurrency)
+
+		for _, n := range notifications {
+			n := n
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				issue, _, _, e := getIssue(ctx, n)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				switch {
+				case isNotFound(e):
+					// deleted or inaccessible, sort without it
+					issues[notificationKey(n)] = nil
+				case e != nil:
+					issues[notificationKey(n)] = nil
+					errors++
+					if err == nil {
+						err = e
+						failed = n
+					}
+				default:
+					issues[notificationKey(n)] = issue
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		msg := NotificationsIssuesLoaded{
+			Issues: issues,
+		}
+
 		if err != nil {
-			return fmt.Errorf("fetching issue: %w", err)
+			f := fail(OpLoadIssues, failed, fmt.Errorf("fetching %d of %d issues: %w", errors, len(notifications), err))
+			msg.Failed = &f
+		}
+
+		return msg
+	}
+}
+
+// PrefetchNotifications loads the issue, labels, comments, and events of notifications
+// before they are opened, at most prefetchConcurrency at a time. Failures are
+// ignored, as the details are loaded again when opened.
+func PrefetchNotifications(notifications []*github.Notification) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, prefetchConcurrency)
+
+		msg := NotificationsPrefetched{
+			Details: make(map[string]Details),
+		}
+
+		for _, n := range notifications {
+			n := n
+			msg.Keys = append(msg.Keys, notificationKey(n))
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				d, ok := prefetchNotification(ctx, n)
+				if !ok {
+					return
+				}
+
+				mu.Lock()
+				msg.Details[notificationKey(n)] = d
+				mu.Unlock()
+			}()
 		}
 
-		return NotificationIssueLoaded{issue}
+		wg.Wait()
+		return msg
+	}
+}
+
+// prefetchNotification loads the details of a notification.
+func prefetchNotification(ctx context.Context, n *github.Notification) (Details, bool) {
+	loaded, ok := LoadNotificationIssue(n)(ctx).(NotificationIssueLoaded)
+	if !ok {
+		return Details{}, false
+	}
+
+	labels, ok := LoadNotificationLabels(n, loaded.Issue)(ctx).(NotificationLabelsLoaded)
+	if !ok {
+		return Details{}, false
+	}
+
+	comments, ok := LoadNotificationComments(n, loaded.Issue)(ctx).(NotificationCommentsLoaded)
+	if !ok {
+		return Details{}, false
 	}
+
+	// events are optional, as older Enterprise versions lack the timeline
+	events, _ := LoadNotificationEvents(n, loaded.Issue)(ctx).(NotificationEventsLoaded)
+
+	return Details{
+		Issue:       loaded.Issue,
+		StateReason: loaded.StateReason,
+		Labels:      labels.Labels,
+		Comments:    comments.Comments,
+		Events:      events.Events,
+	}, true
 }
 
-// LoadNotificationLabels loads a notification's labels.
+// LoadNotificationLabels loads a notification's labels, from the cache when present.
 func LoadNotificationLabels(n *github.Notification, issue *github.Issue) tea.Cmd {
 	return func(ctx context.Context) tea.Msg {
-		ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+		ctx, cancel := withTimeout(cacheFirst(ctx), 1)
 		defer cancel()
 
-		labels, err := getIssueLabels(ctx, n, issue.GetNumber())
+		labels, cached, err := getIssueLabels(ctx, n, issue.GetNumber())
 		if err != nil {
-			return fmt.Errorf("fetching issue labels: %w", err)
+			return fail(OpLoadLabels, n, fmt.Errorf("fetching issue labels: %w", err))
 		}
 
-		return NotificationLabelsLoaded{labels}
+		return NotificationLabelsLoaded{
+			Notification: n,
+			Labels:       labels,
+			Cached:       cached,
+		}
 	}
 }
 
-// LoadNotificationComments loads a notification's comments.
-func LoadNotificationComments(issue *github.Issue) tea.Cmd {
+// LoadNotificationComments loads a notification's comments, from the cache when present.
+func LoadNotificationComments(n *github.Notification, issue *github.Issue) tea.Cmd {
 	return func(ctx context.Context) tea.Msg {
-		ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+		ctx, cancel := withTimeout(cacheFirst(ctx), issue.GetComments()/100+1)
 		defer cancel()
 
-		comments, err := getIssueComments(ctx, issue)
+		comments, cached, err := getIssueComments(ctx, n, issue)
 		if err != nil {
-			return fmt.Errorf("fetching issue comments: %w", err)
+			return fail(OpLoadComments, n, fmt.Errorf("fetching issue comments: %w", err))
 		}
 
-		return NotificationCommentsLoaded{comments}
+		return NotificationCommentsLoaded{
+			Notification: n,
+			Comments:     comments,
+			Cached:       cached,
+		}
 	}
 }
 
-// LoadRepoLabels loads a repo's labels.
+// LoadNotificationEvents loads a notification's timeline events, from the cache when present.
+func LoadNotificationEvents(n *github.Notification, issue *github.Issue) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		ctx, cancel := withTimeout(cacheFirst(ctx), issue.GetComments()/100+1)
+		defer cancel()
+
+		events, cached, err := getIssueEvents(ctx, n, issue)
+		if err != nil {
+			return fail(OpLoadEvents, n, fmt.Errorf("fetching issue timeline: %w", err))
+		}
+
+		return NotificationEventsLoaded{
+			Notification: n,
+			Events:       events,
+			Cached:       cached,
+		}
+	}
+}
+
+// LoadRepoLabels loads a repo's labels, from the cache when present.
 func LoadRepoLabels(n *github.Notification) tea.Cmd {
 	return func(ctx context.Context) tea.Msg {
-		gh := MustClientFromContext(ctx)
+		gh := clientFor(ctx, n)
 
-		ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+		ctx, cancel := withTimeout(cacheFirst(ctx), 1)
 		defer cancel()
 
 		owner, repo := ownerRepo(n)
-		labels, _, err := gh.Issues.ListLabels(ctx, owner, repo, nil)
+		labels, res, err := gh.Issues.ListLabels(ctx, owner, repo, nil)
 		if err != nil {
-			return fmt.Errorf("fetching repo labels: %w", err)
+			return fail(OpLoadRepoLabels, n, fmt.Errorf("fetching repo labels: %w", err))
 		}
 
-		return LabelsLoaded{labels}
+		return LabelsLoaded{
+			Labels: labels,
+			Cached: fromCache(res),
+		}
+	}
+}
+
+// LoadRepoAssignees loads all of a repo's assignable users, from the cache when present.
+func LoadRepoAssignees(n *github.Notification) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		gh := clientFor(ctx, n)
+
+		ctx, cancel := withTimeout(cacheFirst(ctx), 4)
+		defer cancel()
+
+		owner, repo := ownerRepo(n)
+		options := &github.ListOptions{
+			PerPage: 100,
+		}
+
+		var users []*github.User
+		var cached bool
+		for {
+			page, res, err := gh.Issues.ListAssignees(ctx, owner, repo, options)
+			if err != nil {
+				return fail(OpLoadAssignees, n, fmt.Errorf("fetching assignees: %w", err))
+			}
+
+			users = append(users, page...)
+			cached = cached || fromCache(res)
+
+			if res.NextPage == 0 {
+				break
+			}
+
+			options.Page = res.NextPage
+		}
+
+		return AssigneesLoaded{
+			Users:  users,
+			Cached: cached,
+		}
+	}
+}
+
+// LoadReposLabels loads the labels of each notification's repo, de-duplicated by name.
+func LoadReposLabels(notifications []*github.Notification) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		ctx, cancel := withTimeout(ctx, len(notifications))
+		defer cancel()
+
+		var labels []*github.Label
+		seen := make(map[string]bool)
+		for _, n := range notifications {
+			owner, repo := ownerRepo(n)
+			if seen[owner+"/"+repo] {
+				continue
+			}
+			seen[owner+"/"+repo] = true
+
+			gh := clientFor(ctx, n)
+
+			repoLabels, _, err := gh.Issues.ListLabels(ctx, owner, repo, &github.ListOptions{
+				PerPage: 100,
+			})
+
+			if err != nil {
+				return fail(OpLoadRepoLabels, n, fmt.Errorf("fetching repo labels: %w", err))
+			}
+
+			for _, l := range repoLabels {
+				if !seen[l.GetName()] {
+					seen[l.GetName()] = true
+					labels = append(labels, l)
+				}
+			}
+		}
+
+		return LabelsLoaded{Labels: labels}
+	}
+}
+
+// AddNotificationLabels adds labels to an issue.
+func AddNotificationLabels(n *github.Notification, issue *github.Issue, labels []string) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		gh := clientFor(ctx, n)
+
+		ctx, cancel := withTimeout(ctx, 1)
+		defer cancel()
+
+		owner, repo := ownerRepo(n)
+		_, _, err := gh.Issues.AddLabelsToIssue(ctx, owner, repo, issue.GetNumber(), labels)
+		if err != nil {
+			return queue(ctx, Change{
+				Op:           OpUpdateLabels,
+				Notification: n,
+				Issue:        issue,
+				Labels:       labels,
+				Add:          true,
+			}, err, fail(OpUpdateLabels, n, fmt.Errorf("adding labels: %w", err)))
+		}
+
+		return NotificationLabelsUpdated{}
 	}
 }
 
 // UpdateNotificationLabels updates an issue's labels.
 func UpdateNotificationLabels(n *github.Notification, issue *github.Issue, labels []string) tea.Cmd {
 	return func(ctx context.Context) tea.Msg {
-		gh := MustClientFromContext(ctx)
+		gh := clientFor(ctx, n)
 
-		ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+		ctx, cancel := withTimeout(ctx, 1)
 		defer cancel()
 
 		owner, repo := ownerRepo(n)
 
+		change := Change{
+			Op:           OpUpdateLabels,
+			Notification: n,
+			Issue:        issue,
+			Labels:       labels,
+		}
+
 		if len(labels) == 0 {
 			_, err := gh.Issues.RemoveLabelsForIssue(ctx, owner, repo, issue.GetNumber())
 			if err != nil {
-				return fmt.Errorf("removing labels: %w", err)
+				return queue(ctx, change, err, fail(OpUpdateLabels, n, fmt.Errorf("removing labels: %w", err)))
 			}
 		} else {
 			_, _, err := gh.Issues.ReplaceLabelsForIssue(ctx, owner, repo, issue.GetNumber(), labels)
 			if err != nil {
-				return fmt.Errorf("replacing labels: %w", err)
+				return queue(ctx, change, err, fail(OpUpdateLabels, n, fmt.Errorf("replacing labels: %w", err)))
 			}
 		}
 
@@ -163,13 +695,61 @@ func UpdateNotificationLabels(n *github.Notification, issue *github.Issue, label
 	}
 }
 
+// UpdateNotificationAssignees updates an issue's assignees, adding and removing the difference.
+func UpdateNotificationAssignees(n *github.Notification, issue *github.Issue, logins []string) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		gh := clientFor(ctx, n)
+
+		ctx, cancel := withTimeout(ctx, 2)
+		defer cancel()
+
+		owner, repo := ownerRepo(n)
+		current := assigneeLogins(issue)
+
+		var add, remove []string
+		for _, login := range logins {
+			if !includes(current, login) {
+				add = append(add, login)
+			}
+		}
+		for _, login := range current {
+			if !includes(logins, login) {
+				remove = append(remove, login)
+			}
+		}
+
+		updated := issue
+
+		if len(add) > 0 {
+			v, _, err := gh.Issues.AddAssignees(ctx, owner, repo, issue.GetNumber(), add)
+			if err != nil {
+				return fail(OpUpdateAssignees, n, fmt.Errorf("adding assignees: %w", err))
+			}
+			updated = v
+		}
+
+		if len(remove) > 0 {
+			v, _, err := gh.Issues.RemoveAssignees(ctx, owner, repo, issue.GetNumber(), remove)
+			if err != nil {
+				return fail(OpUpdateAssignees, n, fmt.Errorf("removing assignees: %w", err))
+			}
+			updated = v
+		}
+
+		return AssigneesUpdated{
+			Notification: n,
+			Issue:        updated,
+		}
+	}
+}
+
 // UpdateNotificationPriority updates an issue's priority by name.
 func UpdateNotificationPriority(n *github.Notification, issue *github.Issue, name string) tea.Cmd {
 	return func(ctx context.Context) tea.Msg {
-		gh := MustClientFromContext(ctx)
+		gh := clientFor(ctx, n)
 		config := MustConfigFromContext(ctx)
 
-		ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+		ctx, cancel := withTimeout(ctx, 3)
 		defer cancel()
 
 		owner, repo := ownerRepo(n)
@@ -194,23 +774,30 @@ func UpdateNotificationPriority(n *github.Notification, issue *github.Issue, nam
 			Description: &desc,
 		})
 
+		change := Change{
+			Op:           OpUpdatePriority,
+			Notification: n,
+			Issue:        issue,
+			Priority:     name,
+		}
+
 		// ignore error if it already exists
 		if err != nil && !isAlreadyExists(err) {
-			return fmt.Errorf("creating priority label: %w", err)
+			return queue(ctx, change, err, fail(OpUpdatePriority, n, fmt.Errorf("creating priority label: %w", err)))
 		}
 
 		// remove any priority labels
 		for _, p := range config.Priorities {
 			_, err := gh.Issues.RemoveLabelForIssue(ctx, owner, repo, issue.GetNumber(), p.Label)
 			if err != nil && !isNotFound(err) {
-				return fmt.Errorf("error removing label %q: %w", p.Label, err)
+				return queue(ctx, change, err, fail(OpUpdatePriority, n, fmt.Errorf("error removing label %q: %w", p.Label, err)))
 			}
 		}
 
 		// assign the label
 		_, _, err = gh.Issues.AddLabelsToIssue(ctx, owner, repo, issue.GetNumber(), []string{priority.Label})
 		if err != nil {
-			return fmt.Errorf("assigning priority label: %w", err)
+			return queue(ctx, change, err, fail(OpUpdatePriority, n, fmt.Errorf("assigning priority label: %w", err)))
 		}
 
 		return NotificationPriorityUpdated{}
@@ -220,9 +807,9 @@ func UpdateNotificationPriority(n *github.Notification, issue *github.Issue, nam
 // AddComment adds a comment to an issue.
 func AddComment(n *github.Notification, issue *github.Issue, comment string) tea.Cmd {
 	return func(ctx context.Context) tea.Msg {
-		gh := MustClientFromContext(ctx)
+		gh := clientFor(ctx, n)
 
-		ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+		ctx, cancel := withTimeout(ctx, 1)
 		defer cancel()
 
 		owner, repo := ownerRepo(n)
@@ -231,24 +818,173 @@ func AddComment(n *github.Notification, issue *github.Issue, comment string) tea
 		})
 
 		if err != nil {
-			return fmt.Errorf("creating comment: %w", err)
+			return queue(ctx, Change{
+				Op:           OpAddComment,
+				Notification: n,
+				Issue:        issue,
+				Comment:      comment,
+			}, err, fail(OpAddComment, n, fmt.Errorf("creating comment: %w", err)))
 		}
 
 		return CommentAdded{}
 	}
 }
 
+// SubmitReview submits a pull request review.
+func SubmitReview(n *github.Notification, pr *github.PullRequest, event, body string) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		gh := clientFor(ctx, n)
+
+		ctx, cancel := withTimeout(ctx, 1)
+		defer cancel()
+
+		review := &github.PullRequestReviewRequest{
+			Event: &event,
+		}
+
+		if body != "" {
+			review.Body = &body
+		}
+
+		owner, repo := ownerRepo(n)
+		_, _, err := gh.PullRequests.CreateReview(ctx, owner, repo, pr.GetNumber(), review)
+		if err != nil {
+			return fail(OpSubmitReview, n, fmt.Errorf("submitting review: %w", err))
+		}
+
+		return ReviewSubmitted{}
+	}
+}
+
+// CloseIssue closes an issue with a reason such as "not_planned", commenting first when the comment is non-empty.
+func CloseIssue(n *github.Notification, issue *github.Issue, reason, comment string) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		gh := clientFor(ctx, n)
+
+		ctx, cancel := withTimeout(ctx, 2)
+		defer cancel()
+
+		if comment != "" {
+			owner, repo := ownerRepo(n)
+			_, _, err := gh.Issues.CreateComment(ctx, owner, repo, issue.GetNumber(), &github.IssueComment{
+				Body: &comment,
+			})
+
+			if err != nil {
+				return fail(OpUpdateState, n, fmt.Errorf("creating comment: %w", err))
+			}
+		}
+
+		updated, stateReason, err := setIssueState(ctx, n, issue, issueState{
+			State:       "closed",
+			StateReason: reason,
+		})
+
+		if err != nil {
+			return fail(OpUpdateState, n, fmt.Errorf("closing issue: %w", err))
+		}
+
+		return IssueStateUpdated{
+			Notification: n,
+			Issue:        updated,
+			StateReason:  stateReason,
+			Commented:    comment != "",
+		}
+	}
+}
+
+// ReopenIssue reopens a closed issue.
+func ReopenIssue(n *github.Notification, issue *github.Issue) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		ctx, cancel := withTimeout(ctx, 1)
+		defer cancel()
+
+		updated, stateReason, err := setIssueState(ctx, n, issue, issueState{
+			State:       "open",
+			StateReason: "reopened",
+		})
+
+		if err != nil {
+			return fail(OpUpdateState, n, fmt.Errorf("reopening issue: %w", err))
+		}
+
+		return IssueStateUpdated{
+			Notification: n,
+			Issue:        updated,
+			StateReason:  stateReason,
+		}
+	}
+}
+
+// LockIssue locks an issue's conversation, with an optional reason such as "too heated".
+func LockIssue(n *github.Notification, issue *github.Issue, reason string) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		gh := clientFor(ctx, n)
+
+		ctx, cancel := withTimeout(ctx, 1)
+		defer cancel()
+
+		var options *github.LockIssueOptions
+		if reason != "" {
+			options = &github.LockIssueOptions{
+				LockReason: reason,
+			}
+		}
+
+		owner, repo := ownerRepo(n)
+		_, err := gh.Issues.Lock(ctx, owner, repo, issue.GetNumber(), options)
+		if err != nil {
+			return fail(OpLock, n, fmt.Errorf("locking issue: %w", err))
+		}
+
+		// the response is empty, so the lock is applied to a copy
+		locked := true
+		v := *issue
+		v.Locked = &locked
+		v.ActiveLockReason = &reason
+
+		return IssueLockUpdated{n, &v}
+	}
+}
+
+// UnlockIssue unlocks an issue's conversation.
+func UnlockIssue(n *github.Notification, issue *github.Issue) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		gh := clientFor(ctx, n)
+
+		ctx, cancel := withTimeout(ctx, 1)
+		defer cancel()
+
+		owner, repo := ownerRepo(n)
+		_, err := gh.Issues.Unlock(ctx, owner, repo, issue.GetNumber())
+		if err != nil {
+			return fail(OpLock, n, fmt.Errorf("unlocking issue: %w", err))
+		}
+
+		// the response is empty, so the lock is removed from a copy
+		locked := false
+		v := *issue
+		v.Locked = &locked
+		v.ActiveLockReason = nil
+
+		return IssueLockUpdated{n, &v}
+	}
+}
+
 // MarkAsRead marks an issue as read.
 func MarkAsRead(n *github.Notification) tea.Cmd {
 	return func(ctx context.Context) tea.Msg {
-		gh := MustClientFromContext(ctx)
+		gh := clientFor(ctx, n)
 
-		ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+		ctx, cancel := withTimeout(ctx, 1)
 		defer cancel()
 
 		_, err := gh.Activity.MarkThreadRead(ctx, n.GetID())
 		if err != nil {
-			return fmt.Errorf("marking thread as read: %w", err)
+			return queue(ctx, Change{
+				Op:           OpMarkAsRead,
+				Notification: n,
+			}, err, fail(OpMarkAsRead, n, fmt.Errorf("marking thread as read: %w", err)))
 		}
 
 		return MarkedAsRead{n}
@@ -258,36 +994,37 @@ func MarkAsRead(n *github.Notification) tea.Cmd {
 // Unsubscribe unsubscribes from the issue, and marks it as read.
 func Unsubscribe(n *github.Notification) tea.Cmd {
 	return func(ctx context.Context) tea.Msg {
-		gh := MustClientFromContext(ctx)
+		gh := clientFor(ctx, n)
 
-		ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+		ctx, cancel := withTimeout(ctx, 2)
 		defer cancel()
 
 		_, err := gh.Activity.DeleteThreadSubscription(ctx, n.GetID())
 		if err != nil {
-			return fmt.Errorf("removing thread subscription: %w", err)
+			return fail(OpUnsubscribe, n, fmt.Errorf("removing thread subscription: %w", err))
 		}
 
 		_, err = gh.Activity.MarkThreadRead(ctx, n.GetID())
 		if err != nil {
-			return fmt.Errorf("marking thread as read: %w", err)
+			return fail(OpUnsubscribe, n, fmt.Errorf("marking thread as read: %w", err))
 		}
 
 		return Unsubscribed{n}
 	}
 }
 
-// Unwatch unwatches the repository, and marks it as read.
-func Unwatch(owner, repo string) tea.Cmd {
+// Unwatch unwatches the notification's repository.
+func Unwatch(n *github.Notification) tea.Cmd {
 	return func(ctx context.Context) tea.Msg {
-		gh := MustClientFromContext(ctx)
+		gh := clientFor(ctx, n)
 
-		ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+		ctx, cancel := withTimeout(ctx, 2)
 		defer cancel()
 
+		owner, repo := ownerRepo(n)
 		_, err := gh.Activity.DeleteRepositorySubscription(ctx, owner, repo)
 		if err != nil {
-			return fmt.Errorf("unwatching repository: %w", err)
+			return fail(OpUnwatch, n, fmt.Errorf("unwatching repository: %w", err))
 		}
 
 		return Unwatched{
@@ -297,66 +1034,351 @@ func Unwatch(owner, repo string) tea.Cmd {
 	}
 }
 
-// OpenInBrowser opens the in the browser.
+// Resubscribe subscribes to the notification's thread again.
+func Resubscribe(n *github.Notification) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		gh := clientFor(ctx, n)
+
+		ctx, cancel := withTimeout(ctx, 1)
+		defer cancel()
+
+		subscribed := true
+		_, _, err := gh.Activity.SetThreadSubscription(ctx, n.GetID(), &github.Subscription{
+			Subscribed: &subscribed,
+		})
+
+		if err != nil {
+			return fail(OpUndo, n, fmt.Errorf("subscribing to thread: %w", err))
+		}
+
+		return nil
+	}
+}
+
+// Rewatch watches the notification's repository again.
+func Rewatch(n *github.Notification) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		gh := clientFor(ctx, n)
+
+		ctx, cancel := withTimeout(ctx, 1)
+		defer cancel()
+
+		owner, repo := ownerRepo(n)
+		subscribed := true
+		_, _, err := gh.Activity.SetRepositorySubscription(ctx, owner, repo, &github.Subscription{
+			Subscribed: &subscribed,
+		})
+
+		if err != nil {
+			return fail(OpUndo, n, fmt.Errorf("watching repository: %w", err))
+		}
+
+		return nil
+	}
+}
+
+// OpenInBrowser opens the notification subject in the browser, using the
+// html url returned by the API so that enterprise hosts are respected.
 func OpenInBrowser(n *github.Notification) tea.Cmd {
 	return func(ctx context.Context) tea.Msg {
-		gh := MustClientFromContext(ctx)
+		gh := clientFor(ctx, n)
 
 		url := n.Subject.GetURL()
 
+		// subjects such as check suites have no url
+		if url == "" {
+			err := browser.OpenURL(n.GetRepository().GetHTMLURL())
+			if err != nil {
+				return fail(OpOpen, n, fmt.Errorf("opening browser: %w", err))
+			}
+			return nil
+		}
+
 		req, err := gh.NewRequest("GET", url, nil)
 		if err != nil {
-			return err
+			return fail(OpOpen, n, err)
 		}
 
-		ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+		ctx, cancel := withTimeout(ctx, 1)
 		defer cancel()
 
 		var v github.Issue
 		_, err = gh.Do(ctx, req, &v)
 		if err != nil {
-			return fmt.Errorf("fetching issue url: %w", err)
+			return fail(OpOpen, n, fmt.Errorf("fetching issue url: %w", err))
+		}
+
+		err = browser.OpenURL(v.GetHTMLURL())
+		if err != nil {
+			return fail(OpOpen, n, fmt.Errorf("opening browser: %w", err))
+		}
+
+		return nil
+	}
+}
+
+// SaveSortOrder saves the sort order to the "sort" field of the config file,
+// replacing only its value. Nothing is saved when there is no config file.
+func SaveSortOrder(order SortOrder) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fail(OpSaveConfig, nil, fmt.Errorf("locating home directory: %w", err))
+		}
+
+		// the file linked to is replaced, rather than the link
+		path, err := filepath.EvalSymlinks(filepath.Join(home, ConfigPath))
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		if err != nil {
+			return fail(OpSaveConfig, nil, fmt.Errorf("loading config: %w", err))
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return fail(OpSaveConfig, nil, fmt.Errorf("loading config: %w", err))
+		}
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fail(OpSaveConfig, nil, fmt.Errorf("loading config: %w", err))
+		}
+
+		value, err := json.Marshal(order)
+		if err != nil {
+			return fail(OpSaveConfig, nil, fmt.Errorf("encoding sort order: %w", err))
+		}
+
+		b, err = setConfigField(b, "sort", value)
+		if err != nil {
+			return fail(OpSaveConfig, nil, fmt.Errorf("parsing config: %w", err))
+		}
+
+		err = writeFile(path, b, info.Mode().Perm())
+		if err != nil {
+			return fail(OpSaveConfig, nil, fmt.Errorf("saving config: %w", err))
 		}
 
-		return browser.OpenURL(v.GetHTMLURL())
+		return nil
+	}
+}
+
+// writeFile writes a file through a temporary file renamed over it,
+// so that it is never left partially written.
+func writeFile(path string, b []byte, perm os.FileMode) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(b)
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	err = f.Chmod(perm)
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		os.Remove(f.Name())
+		return err
 	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// issueWithReason is an issue with its state reason, which go-github lacks.
+type issueWithReason struct {
+	github.Issue
+	StateReason string `json:"state_reason"`
+}
+
+// issueState is the state of an issue, with the reason it was closed or reopened.
+type issueState struct {
+	State       string `json:"state"`
+	StateReason string `json:"state_reason,omitempty"`
 }
 
-// getIssue returns the issue for the notification.
-func getIssue(ctx context.Context, n *github.Notification) (*github.Issue, error) {
-	gh := MustClientFromContext(ctx)
+// getIssue returns the issue for the notification, its state reason, and whether it was served from the cache.
+func getIssue(ctx context.Context, n *github.Notification) (issue *github.Issue, stateReason string, cached bool, err error) {
+	gh := clientFor(ctx, n)
 	url := n.Subject.GetURL()
 
 	req, err := gh.NewRequest("GET", url, nil)
 	if err != nil {
-		return nil, err
+		return nil, "", false, err
 	}
 
-	var v github.Issue
-	_, err = gh.Do(ctx, req, &v)
-	return &v, err
+	var v issueWithReason
+	res, err := gh.Do(ctx, req, &v)
+	return &v.Issue, v.StateReason, fromCache(res), err
 }
 
-// getIssueLabels returns the labels for the issue.
-func getIssueLabels(ctx context.Context, n *github.Notification, number int) ([]*github.Label, error) {
-	gh := MustClientFromContext(ctx)
+// setIssueState updates the state of an issue, returning the updated issue and its state reason.
+func setIssueState(ctx context.Context, n *github.Notification, issue *github.Issue, state issueState) (*github.Issue, string, error) {
+	gh := clientFor(ctx, n)
 	owner, repo := ownerRepo(n)
-	labels, _, err := gh.Issues.ListLabelsByIssue(ctx, owner, repo, number, nil)
-	return labels, err
+	url := fmt.Sprintf("repos/%s/%s/issues/%d", owner, repo, issue.GetNumber())
+
+	req, err := gh.NewRequest("PATCH", url, state)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var v issueWithReason
+	_, err = gh.Do(ctx, req, &v)
+	return &v.Issue, v.StateReason, err
 }
 
-// getIssueComments returns the comments for an issue.
-func getIssueComments(ctx context.Context, issue *github.Issue) (v []*github.IssueComment, err error) {
-	gh := MustClientFromContext(ctx)
-	url := issue.GetCommentsURL()
+// getPullRequest returns the pull request for the notification.
+func getPullRequest(ctx context.Context, n *github.Notification) (*github.PullRequest, error) {
+	gh := clientFor(ctx, n)
+	url := n.Subject.GetURL()
 
 	req, err := gh.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 
+	var v github.PullRequest
 	_, err = gh.Do(ctx, req, &v)
-	return
+	return &v, err
+}
+
+// getIssueLabels returns the labels for the issue, and whether they were served from the cache.
+func getIssueLabels(ctx context.Context, n *github.Notification, number int) ([]*github.Label, bool, error) {
+	gh := clientFor(ctx, n)
+	owner, repo := ownerRepo(n)
+	labels, res, err := gh.Issues.ListLabelsByIssue(ctx, owner, repo, number, nil)
+	return labels, fromCache(res), err
+}
+
+// getIssueComments returns all pages of comments for an issue, and whether any were served from the cache.
+func getIssueComments(ctx context.Context, n *github.Notification, issue *github.Issue) (comments []*github.IssueComment, cached bool, err error) {
+	gh := clientFor(ctx, n)
+	owner, repo := ownerRepo(n)
+
+	options := &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+
+	for {
+		page, res, err := gh.Issues.ListComments(ctx, owner, repo, issue.GetNumber(), options)
+		if err != nil {
+			return nil, false, err
+		}
+
+		comments = append(comments, page...)
+		cached = cached || fromCache(res)
+
+		if res.NextPage == 0 {
+			return comments, cached, nil
+		}
+
+		options.Page = res.NextPage
+	}
+}
+
+// getIssueEvents returns all pages of timeline events for an issue, and whether any were served from the cache.
+func getIssueEvents(ctx context.Context, n *github.Notification, issue *github.Issue) (events []*github.Timeline, cached bool, err error) {
+	gh := clientFor(ctx, n)
+	owner, repo := ownerRepo(n)
+
+	options := &github.ListOptions{
+		PerPage: 100,
+	}
+
+	for {
+		page, res, err := gh.Issues.ListIssueTimeline(ctx, owner, repo, issue.GetNumber(), options)
+		if err != nil {
+			return nil, false, err
+		}
+
+		events = append(events, page...)
+		cached = cached || fromCache(res)
+
+		if res.NextPage == 0 {
+			return events, cached, nil
+		}
+
+		options.Page = res.NextPage
+	}
+}
+
+// Refresh returns the command with cached responses revalidated, rather than used as-is.
+func Refresh(cmd tea.Cmd) tea.Cmd {
+	return func(ctx context.Context) tea.Msg {
+		return cmd(refreshing(ctx))
+	}
+}
+
+// withTimeout returns a context with a deadline allowing the given number
+// of sequential requests to use all of their attempts per the retry policy.
+func withTimeout(ctx context.Context, requests int) (context.Context, context.CancelFunc) {
+	policy := Retry{
+		Attempts: 1,
+		Timeout:  Duration(time.Second * 10),
+	}
+
+	if c, ok := ConfigFromContext(ctx); ok {
+		if c.Retry.Attempts > 0 {
+			policy.Attempts = c.Retry.Attempts
+		}
+		if c.Retry.Timeout > 0 {
+			policy.Timeout = c.Retry.Timeout
+		}
+	}
+
+	attempt := time.Duration(policy.Timeout) + maxBackoff
+	return context.WithTimeout(ctx, time.Duration(requests*policy.Attempts)*attempt)
+}
+
+// mustClients returns the account clients from context, or the default client as a single unnamed account.
+func mustClients(ctx context.Context) *Clients {
+	if clients, ok := ClientsFromContext(ctx); ok {
+		return clients
+	}
+	clients := NewClients()
+	clients.Add("", MustClientFromContext(ctx), nil)
+	return clients
+}
+
+// accountPrefix returns the account name followed by a space, or an empty string for the default account.
+func accountPrefix(name string) string {
+	if name == "" {
+		return ""
+	}
+	return name + " "
+}
+
+// fail returns a Failed msg for the operation, and notification when present.
+func fail(op Op, n *github.Notification, err error) Failed {
+	return Failed{
+		Op:           op,
+		Notification: n,
+		Err:          err,
+		Time:         time.Now(),
+	}
+}
+
+// pollInterval returns the X-Poll-Interval of the response, defaulting to one minute.
+func pollInterval(res *github.Response) time.Duration {
+	seconds, err := strconv.Atoi(res.Header.Get("X-Poll-Interval"))
+	if err != nil || seconds <= 0 {
+		return time.Minute
+	}
+	return time.Duration(seconds) * time.Second
 }
 
 // isNotFound returns true the error is a 404.
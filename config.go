@@ -2,7 +2,11 @@ package triage
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"time"
 
+	"github.com/google/go-github/v28/github"
 	"github.com/tj/go-termd"
 )
 
@@ -18,12 +22,157 @@ type Priority struct {
 	Color string `json:"color"`
 }
 
+// ConfigPath is the config file path, relative to the home directory.
+const ConfigPath = ".triage.json"
+
+// SavedView is a named notifications search query.
+type SavedView struct {
+	// Name of the view.
+	Name string `json:"name"`
+
+	// Query is the search query, for example "reason:mention is:unread".
+	Query string `json:"query"`
+}
+
+// Host is the configuration of the GitHub host, used for GitHub Enterprise Server.
+type Host struct {
+	// URL is the API URL, for example "https://github.example.com/api/v3/".
+	// GitHub.com is used when empty.
+	URL string `json:"url"`
+
+	// UploadURL is the uploads API URL, derived from URL when empty.
+	UploadURL string `json:"upload_url"`
+
+	// CABundle is the path to a PEM encoded certificate bundle, used
+	// to verify hosts with certificates signed by a private authority.
+	CABundle string `json:"ca_bundle"`
+}
+
+// Account is the configuration of a GitHub account.
+type Account struct {
+	// Name of the account, displayed as a badge, for example "work".
+	Name string `json:"name"`
+
+	// Token is the personal access token of the account,
+	// discovered from other sources when empty.
+	Token string `json:"token"`
+
+	// TokenCommand is a shell command which outputs the token, tried
+	// before the other sources, for example a password manager.
+	TokenCommand string `json:"token_command"`
+
+	// Host is the GitHub host of the account, GitHub.com when empty.
+	Host
+}
+
+// Retry is the retry policy of API requests.
+type Retry struct {
+	// Attempts is the maximum number of attempts of each request.
+	Attempts int `json:"attempts"`
+
+	// Timeout is the timeout of each attempt, for example "10s".
+	Timeout Duration `json:"timeout"`
+}
+
+// Duration is a time.Duration represented in JSON as a string such as "10s".
+type Duration time.Duration
+
+// MarshalJSON implementation.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implementation.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(v)
+	return nil
+}
+
+// Filter is a set of notification subject types and reasons to include or exclude.
+type Filter struct {
+	// Types is the subject types included, for example "Issue" or "PullRequest".
+	// All types are included when empty.
+	Types []string `json:"types"`
+
+	// ExcludeTypes is the subject types excluded, for example "Release".
+	ExcludeTypes []string `json:"exclude_types"`
+
+	// Reasons is the notification reasons included, for example "mention".
+	// All reasons are included when empty.
+	Reasons []string `json:"reasons"`
+
+	// ExcludeReasons is the notification reasons excluded, for example "subscribed".
+	ExcludeReasons []string `json:"exclude_reasons"`
+}
+
+// Match returns true if the notification passes the filter.
+func (f Filter) Match(n *github.Notification) bool {
+	kind := n.GetSubject().GetType()
+	reason := n.GetReason()
+
+	if len(f.Types) > 0 && !includes(f.Types, kind) {
+		return false
+	}
+
+	if len(f.Reasons) > 0 && !includes(f.Reasons, reason) {
+		return false
+	}
+
+	return !includes(f.ExcludeTypes, kind) && !includes(f.ExcludeReasons, reason)
+}
+
 // Config is the user configuration.
 type Config struct {
 	// Priorities is a set of priorities used in assigning. By default
 	// low, medium, and high are provided.
 	Priorities []Priority
 
+	// GitHub is the GitHub host configuration, the GITHUB_API_URL
+	// environment variable may be used to override the URL.
+	GitHub Host `json:"github"`
+
+	// Accounts is a set of GitHub accounts, whose notifications are
+	// merged into one list. A single account using the GitHub host
+	// is used when empty.
+	Accounts []Account `json:"accounts"`
+
+	// TokenCommand is a shell command which outputs the token of the
+	// default account, used when no other source has one.
+	TokenCommand string `json:"token_command"`
+
+	// Retry is the retry policy of requests which fail with
+	// server errors, timeouts, or connection resets.
+	Retry Retry `json:"retry"`
+
+	// MaxPages is the maximum number of notification pages fetched,
+	// 100 notifications per page. Defaults to 10, and -1 removes the limit.
+	MaxPages int `json:"max_pages"`
+
+	// Views is a set of named search queries, selected with the number keys.
+	Views []SavedView `json:"views"`
+
+	// Filter is the subject types and reasons displayed, by
+	// default releases are hidden. Hidden notifications may be
+	// shown temporarily with the H key.
+	Filter *Filter `json:"filter"`
+
+	// Sort is the notifications sort order, toggled with the s key.
+	Sort SortOrder `json:"sort"`
+
+	// CollapseComments collapses the comments made before the notification
+	// was last read, which may be expanded with the C key.
+	CollapseComments bool `json:"collapse_comments"`
+
 	// Theme is style related configuration.
 	Theme struct {
 		// Code is the syntax theme used for highlighting blocks of code.
@@ -53,3 +202,111 @@ func MustConfigFromContext(ctx context.Context) *Config {
 	}
 	return v
 }
+
+// setConfigField returns the config with the top-level field set to the JSON value,
+// replacing only the existing value so the formatting and order of the file are
+// retained, or appending the field when missing.
+func setConfigField(b []byte, name string, value []byte) ([]byte, error) {
+	if !json.Valid(b) {
+		return nil, errors.New("invalid JSON")
+	}
+
+	i := skipSpace(b, 0)
+	if b[i] != '{' {
+		return nil, errors.New("expected an object")
+	}
+
+	key, _ := json.Marshal(name)
+	last := -1
+	i++
+
+	for {
+		i = skipSpace(b, i)
+
+		switch b[i] {
+		case '}':
+			at, field := i, append(append(key, ": "...), value...)
+			if last != -1 {
+				at, field = last, append([]byte(",\n  "), field...)
+			}
+			return splice(b, at, at, field), nil
+		case ',':
+			i++
+			continue
+		}
+
+		// key
+		end := skipString(b, i)
+		var k string
+		json.Unmarshal(b[i:end+1], &k)
+
+		// value, following the colon
+		i = skipSpace(b, skipSpace(b, end+1)+1)
+		start := i
+		i = skipValue(b, i)
+
+		if k == name {
+			return splice(b, start, i, value), nil
+		}
+
+		last = i
+	}
+}
+
+// splice returns a copy of b with the bytes from the index up to another replaced by v.
+func splice(b []byte, from, to int, v []byte) []byte {
+	return append(append(append([]byte{}, b[:from]...), v...), b[to:]...)
+}
+
+// skipSpace returns the index of the first non-whitespace byte from i.
+func skipSpace(b []byte, i int) int {
+	for i < len(b) && isSpace(b[i]) {
+		i++
+	}
+	return i
+}
+
+// skipString returns the index of the closing quote of the string starting at i.
+func skipString(b []byte, i int) int {
+	for i++; i < len(b); i++ {
+		switch b[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return i
+}
+
+// skipValue returns the index following the JSON value starting at i.
+func skipValue(b []byte, i int) int {
+	var depth int
+	for ; i < len(b); i++ {
+		switch c := b[i]; {
+		case c == '"':
+			i = skipString(b, i)
+			if depth == 0 {
+				return i + 1
+			}
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			if depth == 0 {
+				return i
+			}
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		case depth == 0 && (c == ',' || isSpace(c)):
+			return i
+		}
+	}
+	return i
+}
+
+// isSpace returns true if the byte is JSON whitespace.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,56 @@
+package triage
+
+import (
+	"testing"
+)
+
+func TestSetConfigField(t *testing.T) {
+	cases := []struct {
+		name   string
+		config string
+		result string
+	}{
+		{"empty", `{}`, `{"sort": "age"}`},
+		{"missing", "{\n  \"max_pages\": 5\n}\n", "{\n  \"max_pages\": 5,\n  \"sort\": \"age\"\n}\n"},
+		{"replaced", "{\n  \"sort\": \"updated\",\n  \"max_pages\": 5\n}\n", "{\n  \"sort\": \"age\",\n  \"max_pages\": 5\n}\n"},
+		{"replaced last", `{"max_pages":5,"sort":"updated"}`, `{"max_pages":5,"sort":"age"}`},
+		{"nested field untouched", `{"views": [{"sort": "x"}], "theme": {"sort": {}}}`, "{\"views\": [{\"sort\": \"x\"}], \"theme\": {\"sort\": {}},\n  \"sort\": \"age\"}"},
+		{"escaped key", `{"so\"rt": "x", "sort": null}`, `{"so\"rt": "x", "sort": "age"}`},
+		{"string with braces", `{"token_command": "echo }{,", "sort": "updated"}`, `{"token_command": "echo }{,", "sort": "age"}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b, err := setConfigField([]byte(c.config), "sort", []byte(`"age"`))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if string(b) != c.result {
+				t.Fatalf("expected %q, got %q", c.result, b)
+			}
+		})
+	}
+}
+
+func TestSetConfigField_invalid(t *testing.T) {
+	cases := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{"invalid", `{"sort":`, "invalid JSON"},
+		{"array", `["sort"]`, "expected an object"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := setConfigField([]byte(c.config), "sort", []byte(`"age"`))
+			if err == nil {
+				t.Fatalf("expected error %q", c.err)
+			}
+			if err.Error() != c.err {
+				t.Fatalf("expected error %q, got %q", c.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package triage
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/alecthomas/chroma"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/google/go-github/v28/github"
+	"github.com/tj/go-termd"
+
+	"github.com/tj/triage/internal/colors"
+)
+
+// diff is a rendered unified diff.
+type diff struct {
+	// Text is the rendered diff.
+	Text string
+
+	// Files is the line offset of each file.
+	Files []int
+
+	// Hunks is the line offset of each hunk.
+	Hunks []int
+
+	// Lines is the number of lines.
+	Lines int
+}
+
+// renderDiff returns the rendered diff of the files, highlighting
+// code with the given highlighter when present.
+func renderDiff(files []*github.CommitFile, highlighter termd.SyntaxHighlighter) (d diff) {
+	w := new(bytes.Buffer)
+	var line int
+
+	writeln := func(format string, v ...interface{}) {
+		fmt.Fprintf(w, format+"\r\n", v...)
+		line++
+	}
+
+	for _, f := range files {
+		d.Files = append(d.Files, line)
+
+		// header
+		writeln("    %s %s %s %s",
+			colors.Bold(f.GetFilename()),
+			colors.Gray(f.GetStatus()),
+			colors.Purple(fmt.Sprintf("+%d", f.GetAdditions())),
+			colors.Red(fmt.Sprintf("-%d", f.GetDeletions())))
+		writeln("")
+
+		// binary or too large
+		if f.GetPatch() == "" {
+			writeln("    %s", colors.Gray("No diff available."))
+			writeln("")
+			continue
+		}
+
+		// hunks
+		for _, l := range highlightPatch(f.GetFilename(), f.GetPatch(), highlighter) {
+			if strings.HasPrefix(l, "@@") {
+				d.Hunks = append(d.Hunks, line)
+				writeln("    %s", colors.Cyan(l))
+				continue
+			}
+			writeln("    %s", l)
+		}
+		writeln("")
+	}
+
+	d.Text = w.String()
+	d.Lines = line
+	return
+}
+
+// highlightPatch returns the lines of a patch, with the code of each line
+// highlighted using the lexer for the filename when available.
+func highlightPatch(filename, patch string, highlighter termd.SyntaxHighlighter) (lines []string) {
+	raw := strings.Split(strings.Replace(patch, "\r\n", "\n", -1), "\n")
+
+	// strip the +/- markers so that the code is lexed as a whole
+	var code []string
+	for _, l := range raw {
+		if l == "" || l[0] == '@' || l[0] == '\\' {
+			code = append(code, "")
+			continue
+		}
+		code = append(code, strings.Replace(l[1:], "\t", "  ", -1))
+	}
+
+	highlighted := highlightCode(filename, strings.Join(code, "\n"), highlighter)
+	if highlighted == nil {
+		highlighted = code
+	}
+
+	for i, l := range raw {
+		switch {
+		case l == "":
+			lines = append(lines, "")
+		case l[0] == '@':
+			lines = append(lines, l)
+		case l[0] == '\\':
+			lines = append(lines, colors.Gray(l))
+		case l[0] == '+':
+			lines = append(lines, colors.Purple("+")+highlighted[i])
+		case l[0] == '-':
+			lines = append(lines, colors.Red("-")+highlighted[i])
+		default:
+			lines = append(lines, " "+highlighted[i])
+		}
+	}
+
+	return
+}
+
+// highlightCode returns the highlighted lines of code, or nil when
+// no highlighter or lexer for the filename is available.
+func highlightCode(filename, code string, highlighter termd.SyntaxHighlighter) []string {
+	if highlighter == nil {
+		return nil
+	}
+
+	l := lexers.Match(path.Base(filename))
+	if l == nil {
+		return nil
+	}
+
+	it, err := chroma.Coalesce(l).Tokenise(nil, code)
+	if err != nil {
+		return nil
+	}
+
+	// tokens may span lines, so each line is styled separately
+	// to prevent escape sequences leaking between lines
+	var w strings.Builder
+	for _, t := range it.Tokens() {
+		parts := strings.Split(t.Value, "\n")
+		for i, p := range parts {
+			if i > 0 {
+				w.WriteString("\n")
+			}
+			if p != "" {
+				w.WriteString(highlighter.Token(chroma.Token{Type: t.Type, Value: p}))
+			}
+		}
+	}
+
+	lines := strings.Split(w.String(), "\n")
+	if n := strings.Count(code, "\n") + 1; len(lines) < n {
+		return nil
+	}
+	return lines
+}
+
+// nextOffset returns the first offset after the scroll position, or the scroll position.
+func nextOffset(offsets []int, scroll int) int {
+	for _, o := range offsets {
+		if o > scroll {
+			return o
+		}
+	}
+	return scroll
+}
+
+// prevOffset returns the last offset before the scroll position, or zero.
+func prevOffset(offsets []int, scroll int) int {
+	prev := 0
+	for _, o := range offsets {
+		if o >= scroll {
+			break
+		}
+		prev = o
+	}
+	return prev
+}
+
+// clampDiffScroll returns the scroll position bounded so that the
+// last page of the diff remains in view.
+func clampDiffScroll(m Model, scroll int) int {
+	return max(0, min(scroll, m.DiffLines-(m.Height-6)))
+}
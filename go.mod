@@ -4,6 +4,7 @@ go 1.13
 
 require (
 	github.com/AstromechZA/terminfo v0.0.0-20170409122644-e59d11a175fb
+	github.com/alecthomas/chroma v0.6.8
 	github.com/aybabtme/rgbterm v0.0.0-20170906152045-cc83f3b3ce59
 	github.com/dustin/go-humanize v1.0.0
 	github.com/google/go-github/v28 v28.1.1
@@ -0,0 +1,184 @@
+package triage
+
+import (
+	"github.com/google/go-github/v28/github"
+)
+
+// groupHeaderHeight is the number of rows a repository header consumes.
+var groupHeaderHeight = 2
+
+// groupedItemHeight is the number of rows a grouped list item consumes.
+var groupedItemHeight = 3
+
+// listItem is a row of the notifications list, which is either
+// a notification, or a repository header when grouped.
+type listItem struct {
+	// Repo is the "owner/repo" name of the repository.
+	Repo string
+
+	// Header is true for repository headers.
+	Header bool
+
+	// Grouped is true for notifications listed under a header.
+	Grouped bool
+
+	// Notifications is the notifications of a header's repository,
+	// or the notification itself.
+	Notifications []*github.Notification
+}
+
+// Notification returns the notification, or nil for headers.
+func (i listItem) Notification() *github.Notification {
+	if i.Header {
+		return nil
+	}
+	return i.Notifications[0]
+}
+
+// Height returns the number of rows the item consumes.
+func (i listItem) Height() int {
+	switch {
+	case i.Header:
+		return groupHeaderHeight
+	case i.Grouped:
+		return groupedItemHeight
+	default:
+		return listItemHeight
+	}
+}
+
+// listItems returns the rows of the notifications list, grouping
+// the notifications under repository headers when enabled.
+func listItems(m Model, notifications []*github.Notification) (items []listItem) {
+	if !m.Grouped {
+		for _, n := range notifications {
+			items = append(items, listItem{
+				Repo:          n.GetRepository().GetFullName(),
+				Notifications: []*github.Notification{n},
+			})
+		}
+		return
+	}
+
+	repos, groups := groupNotifications(notifications)
+	for _, repo := range repos {
+		items = append(items, listItem{
+			Repo:          repo,
+			Header:        true,
+			Notifications: groups[repo],
+		})
+
+		if m.Collapsed[repo] {
+			continue
+		}
+
+		for _, n := range groups[repo] {
+			items = append(items, listItem{
+				Repo:          repo,
+				Grouped:       true,
+				Notifications: []*github.Notification{n},
+			})
+		}
+	}
+
+	return
+}
+
+// groupNotifications returns the notifications grouped by repository,
+// with repositories in the order they first appear.
+func groupNotifications(notifications []*github.Notification) (repos []string, groups map[string][]*github.Notification) {
+	groups = make(map[string][]*github.Notification)
+	for _, n := range notifications {
+		repo := n.GetRepository().GetFullName()
+		if _, ok := groups[repo]; !ok {
+			repos = append(repos, repo)
+		}
+		groups[repo] = append(groups[repo], n)
+	}
+	return
+}
+
+// getItemIndex returns the index of the item, matching headers
+// by repository, and notifications by id, or -1.
+func getItemIndex(items []listItem, item listItem) int {
+	for i, v := range items {
+		if v.Header != item.Header || v.Repo != item.Repo {
+			continue
+		}
+		if v.Header || notificationKey(v.Notification()) == notificationKey(item.Notification()) {
+			return i
+		}
+	}
+	return -1
+}
+
+// getNotificationItemIndex returns the index of the notification's item, or -1.
+func getNotificationItemIndex(items []listItem, key string) int {
+	for i, v := range items {
+		if !v.Header && notificationKey(v.Notification()) == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// itemsHeight returns the number of rows consumed by the items.
+func itemsHeight(items []listItem) (height int) {
+	for _, v := range items {
+		height += v.Height()
+	}
+	return
+}
+
+// toggleCollapsed toggles the collapsed state of the repository.
+func toggleCollapsed(m Model, repo string) Model {
+	collapsed := make(map[string]bool)
+	for k, v := range m.Collapsed {
+		collapsed[k] = v
+	}
+
+	if collapsed[repo] {
+		delete(collapsed, repo)
+	} else {
+		collapsed[repo] = true
+	}
+
+	m.Collapsed = collapsed
+	return m
+}
+
+// selectedItem returns the selected list item, if any.
+func selectedItem(m Model) (listItem, bool) {
+	items := listItems(m, visibleNotifications(m))
+	if m.Selected >= len(items) {
+		return listItem{}, false
+	}
+	return items[m.Selected], true
+}
+
+// selectItem selects the list item, or the header of its repository
+// when collapsed, scrolling it into view.
+func selectItem(m Model, item listItem) Model {
+	items := listItems(m, visibleNotifications(m))
+
+	i := -1
+	if n := item.Notification(); n != nil {
+		i = getNotificationItemIndex(items, notificationKey(n))
+	}
+
+	if i == -1 {
+		i = getItemIndex(items, listItem{Repo: item.Repo, Header: true})
+	}
+
+	if i == -1 && len(item.Notifications) > 0 {
+		i = getNotificationItemIndex(items, notificationKey(item.Notifications[0]))
+	}
+
+	if i == -1 {
+		return m
+	}
+
+	m.Selected = i
+	m.NotificationsScrollY = scrollNotifications(m, items, 1)
+	return m
+}
@@ -2,6 +2,7 @@ package triage
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/go-github/v28/github"
 	"github.com/tj/go-tea"
@@ -20,29 +21,110 @@ const (
 	PageLabels
 	PageComment
 	PagePriorities
+	PageErrors
+	PageReview
+	PageDiff
+	PageClose
+	PageLock
+	PageAssignees
 )
 
+// Op is an operation performed by a command.
+type Op int
+
+// Operations available.
+const (
+	OpLoadNotifications Op = iota
+	OpPollNotifications
+	OpLoadIssue
+	OpLoadLabels
+	OpLoadComments
+	OpLoadPullRequest
+	OpLoadReviews
+	OpLoadChecks
+	OpLoadDiff
+	OpLoadRepoLabels
+	OpUpdateLabels
+	OpUpdatePriority
+	OpAddComment
+	OpSubmitReview
+	OpMarkAsRead
+	OpUnsubscribe
+	OpUnwatch
+	OpOpen
+	OpUndo
+	OpSaveConfig
+	OpReplay
+	OpLoadEvents
+	OpUpdateState
+	OpLock
+	OpLoadAssignees
+	OpUpdateAssignees
+	OpLoadIssues
+	OpGetDimensions
+)
+
+// ViewPosition is the selection and scroll position of a saved view.
+type ViewPosition struct {
+	Selected int
+	ScrollY  int
+}
+
 // Model is the application model.
 type Model struct {
 	// active page
 	Page
 
 	// notifications page
+	Views                []SavedView
+	ActiveView           int
+	ViewPositions        map[int]ViewPosition
 	Notifications        []*github.Notification
 	NotificationsScrollY int
 	Selected             int
 	Searching            bool
 	SearchInput          input.Model
+	PendingNotifications []*github.Notification
+	LoadingPage          int
+	Highlighted          map[string]bool
+	Checked              map[string]bool
+	Grouped              bool
+	Collapsed            map[string]bool
+	Sort                 SortOrder
+	Filter               *Filter
+	ShowHidden           bool
+	Issues               map[string]*github.Issue
+	Prefetched           map[string]Details
+	Prefetching          map[string]bool
+
+	// polling
+	PollID       int
+	LastModified map[string]string
+	PollInterval time.Duration
 
 	// notification page
 	Notification        *github.Notification
 	NotificationScrollY int
 	Labels              []*github.Label
 	Issue               *github.Issue
+	StateReason         string
+	PendingState        string
 	Comments            []*github.IssueComment
+	ShowOlderComments   bool
+	Events              []*github.Timeline
+	HideEvents          bool
 	LoadingIssue        bool
 	LoadingLabels       bool
 	LoadingComments     bool
+	LoadingEvents       bool
+	LabelsLoaded        bool
+
+	// pull request notification
+	PullRequest        *github.PullRequest
+	Reviews            []*github.PullRequestReview
+	CheckRuns          []*github.CheckRun
+	Status             *github.CombinedStatus
+	LoadingPullRequest bool
 
 	// priorities page
 	PriorityOptions option.Model
@@ -51,22 +133,75 @@ type Model struct {
 	LabelOptions options.Model
 	RepoLabels   []*github.Label
 
+	// assignees page
+	AssigneeOptions options.Model
+	RepoAssignees   []*github.User
+
 	// comment
 	CommentInput input.Model
 
+	// diff page
+	DiffText        string
+	DiffLines       int
+	DiffFileOffsets []int
+	DiffHunkOffsets []int
+	DiffScrollY     int
+	LoadingDiff     bool
+
+	// review page
+	ReviewOptions option.Model
+	ReviewEvent   string
+	ReviewInput   input.Model
+
+	// close page
+	CloseOptions option.Model
+	CloseReason  string
+	CloseInput   input.Model
+
+	// lock page
+	LockOptions option.Model
+
+	// errors page
+	Errors        []Failed
+	ErrorsScrollY int
+	ErrorsReturn  Page
+	Toast         bool
+	ToastID       int
+
 	// shared
-	MarkingAsRead bool
-	Unsubscribing bool
-	Unwatching    bool
-	Loading       bool
-	Width         int
-	Height        int
+	UndoStack   []Undo
+	Batch       Batch
+	BulkEditing bool
+	Notice      string
+	NoticeID    int
+	Rate        github.Rate
+	Offline     bool
+	Queued      int
+	Replaying   bool
+	Loading     bool
+	Width       int
+	Height      int
 }
 
 // Init function.
 func Init(ctx context.Context) (tea.Model, tea.Cmd) {
+	config := MustConfigFromContext(ctx)
+
+	// the "All" view is always available
+	views := append([]SavedView{{Name: "All"}}, config.Views...)
+
+	// changes queued while offline in a previous session
+	var queued int
+	if outbox, ok := OutboxFromContext(ctx); ok {
+		queued = outbox.Len()
+	}
+
 	return Model{
 		Page:    PageNotifications,
+		Views:   views,
+		Sort:    config.Sort,
+		Filter:  config.Filter,
+		Queued:  queued,
 		Loading: true,
 	}, GetDimensions
 }
@@ -0,0 +1,346 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/tj/go-tea"
+)
+
+// OutboxPath is the outbox file path relative to the home directory.
+const OutboxPath = ".triage-outbox.json"
+
+// Change is a write action queued while offline.
+type Change struct {
+	// Op is the operation, one of OpMarkAsRead, OpUpdateLabels, OpUpdatePriority, or OpAddComment.
+	Op Op `json:"op"`
+
+	// Account is the name of the account the notification was fetched with.
+	Account string `json:"account"`
+
+	// Notification is the notification changed.
+	Notification *github.Notification `json:"notification"`
+
+	// Issue is the notification's issue, when the change applies to it.
+	Issue *github.Issue `json:"issue,omitempty"`
+
+	// Base is the issue's label names displayed when the change was made,
+	// used to detect conflicting label changes made since. It is nil when
+	// unknown, such as for bulk changes, which are not checked for conflicts.
+	Base []string `json:"base"`
+
+	// Labels is the label names to set, or to add when Add is true.
+	Labels []string `json:"labels,omitempty"`
+
+	// Add is true when Labels are added rather than replacing the issue's labels.
+	Add bool `json:"add,omitempty"`
+
+	// Priority is the name of the priority to set.
+	Priority string `json:"priority,omitempty"`
+
+	// Comment is the comment to add.
+	Comment string `json:"comment,omitempty"`
+
+	// QueuedAt is the time the change was queued.
+	QueuedAt time.Time `json:"queued_at"`
+}
+
+// Outbox is a durable queue of changes made while offline, which are
+// replayed in order when connectivity returns.
+type Outbox struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewOutbox returns an outbox stored at path.
+func NewOutbox(path string) *Outbox {
+	return &Outbox{path: path}
+}
+
+// Len returns the number of queued changes.
+func (o *Outbox) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	changes, _ := o.load()
+	return len(changes)
+}
+
+// Push appends a change, returning the number of queued changes.
+func (o *Outbox) Push(c Change) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	changes, err := o.load()
+	if err != nil {
+		return 0, err
+	}
+
+	changes = append(changes, c)
+	return len(changes), o.save(changes)
+}
+
+// list returns the queued changes.
+func (o *Outbox) list() ([]Change, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.load()
+}
+
+// shift removes the first queued change, once it has been replayed,
+// returning the number of changes remaining.
+func (o *Outbox) shift() (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	changes, err := o.load()
+	if err != nil {
+		return 0, err
+	}
+
+	if len(changes) > 0 {
+		changes = changes[1:]
+	}
+
+	return len(changes), o.save(changes)
+}
+
+// load the queued changes.
+func (o *Outbox) load() ([]Change, error) {
+	b, err := ioutil.ReadFile(o.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("reading outbox: %w", err)
+	}
+
+	var changes []Change
+	err = json.Unmarshal(b, &changes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing outbox: %w", err)
+	}
+
+	return changes, nil
+}
+
+// save the queued changes, removing the file when empty.
+func (o *Outbox) save(changes []Change) error {
+	if len(changes) == 0 {
+		err := os.Remove(o.path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	b, err := json.MarshalIndent(changes, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write atomically so a crash never loses the queue
+	tmp := filepath.Join(filepath.Dir(o.path), "."+filepath.Base(o.path)+".tmp")
+	err = ioutil.WriteFile(tmp, b, 0600)
+	if err != nil {
+		return fmt.Errorf("writing outbox: %w", err)
+	}
+
+	return os.Rename(tmp, o.path)
+}
+
+// outboxKey is the context key of the outbox.
+type outboxKey struct{}
+
+// NewOutboxContext returns a new context with the outbox.
+func NewOutboxContext(ctx context.Context, v *Outbox) context.Context {
+	return context.WithValue(ctx, outboxKey{}, v)
+}
+
+// OutboxFromContext returns the outbox from context, if any.
+func OutboxFromContext(ctx context.Context) (*Outbox, bool) {
+	v, ok := ctx.Value(outboxKey{}).(*Outbox)
+	return v, ok && v != nil
+}
+
+// isOffline returns true if the error is due to the API being unreachable,
+// in which case the request was never sent.
+func isOffline(err error) bool {
+	return isDialError(err)
+}
+
+// queue queues the change when the error is due to being offline, returning
+// a Queued msg, or the failure otherwise.
+func queue(ctx context.Context, c Change, err error, failure Failed) tea.Msg {
+	outbox, ok := OutboxFromContext(ctx)
+	if !ok || !isOffline(err) {
+		return failure
+	}
+
+	if clients, ok := ClientsFromContext(ctx); ok {
+		c.Account, _ = clients.Account(c.Notification)
+	}
+
+	c.Base = issueLabelNames(c.Issue)
+	c.QueuedAt = time.Now()
+
+	pending, e := outbox.Push(c)
+	if e != nil {
+		return fail(failure.Op, failure.Notification, fmt.Errorf("queueing offline change: %w", e))
+	}
+
+	return Queued{
+		Change:  c,
+		Pending: pending,
+	}
+}
+
+// ReplayOutbox sends the queued changes in order, stopping when offline. Label
+// and priority changes are discarded and reported as conflicts when the issue's
+// labels have changed since they were queued. The outbox is not locked while
+// sending, so changes may be queued meanwhile, but only one replay may run at once.
+func ReplayOutbox(ctx context.Context) tea.Msg {
+	var msg OutboxReplayed
+
+	outbox, ok := OutboxFromContext(ctx)
+	if !ok {
+		return msg
+	}
+
+	changes, err := outbox.list()
+	if err != nil {
+		msg.Failed = append(msg.Failed, fail(OpReplay, nil, err))
+		return msg
+	}
+
+	// replayed commands must fail rather than queue again
+	replayCtx := NewOutboxContext(ctx, nil)
+
+	// issues changed by this replay, which are expected to differ from their base
+	changed := make(map[string]bool)
+
+	for _, c := range changes {
+		if clients, ok := ClientsFromContext(ctx); ok && c.Account != "" {
+			clients.Register(c.Account, []*github.Notification{c.Notification})
+		}
+
+		f, ok := replay(replayCtx, c, changed[c.Issue.GetURL()])
+
+		// still offline, the remaining changes are kept
+		if !ok && isOffline(f.Err) {
+			msg.Offline = true
+			break
+		}
+
+		if ok {
+			msg.Sent++
+			if c.Op == OpMarkAsRead {
+				msg.Read = append(msg.Read, notificationKey(c.Notification))
+			}
+			if c.Issue != nil {
+				changed[c.Issue.GetURL()] = true
+			}
+		} else {
+			msg.Failed = append(msg.Failed, f)
+		}
+
+		_, err = outbox.shift()
+		if err != nil {
+			msg.Failed = append(msg.Failed, fail(OpReplay, nil, err))
+			break
+		}
+	}
+
+	// including any changes queued during the replay
+	msg.Pending = outbox.Len()
+	return msg
+}
+
+// replay sends a queued change, returning the failure if it did not succeed.
+func replay(ctx context.Context, c Change, changed bool) (Failed, bool) {
+	n := c.Notification
+
+	// conflicting label changes, additions never conflict
+	if !changed && !c.Add && c.Base != nil && (c.Op == OpUpdateLabels || c.Op == OpUpdatePriority) {
+		timeoutCtx, cancel := withTimeout(refreshing(ctx), 1)
+		labels, _, err := getIssueLabels(timeoutCtx, n, c.Issue.GetNumber())
+		cancel()
+
+		if err != nil {
+			return fail(c.Op, n, fmt.Errorf("fetching issue labels: %w", err)), false
+		}
+
+		if current := labelNames(labels); !sameNames(current, c.Base) {
+			return fail(c.Op, n, fmt.Errorf("conflict: labels of #%d changed from %s to %s while offline, change discarded",
+				c.Issue.GetNumber(),
+				formatNames(c.Base),
+				formatNames(current))), false
+		}
+	}
+
+	var cmd tea.Cmd
+	switch c.Op {
+	case OpMarkAsRead:
+		cmd = MarkAsRead(n)
+	case OpUpdateLabels:
+		if c.Add {
+			cmd = AddNotificationLabels(n, c.Issue, c.Labels)
+		} else {
+			cmd = UpdateNotificationLabels(n, c.Issue, c.Labels)
+		}
+	case OpUpdatePriority:
+		cmd = UpdateNotificationPriority(n, c.Issue, c.Priority)
+	case OpAddComment:
+		cmd = AddComment(n, c.Issue, c.Comment)
+	default:
+		return fail(c.Op, n, errors.New("unsupported queued change")), false
+	}
+
+	if f, ok := cmd(ctx).(Failed); ok {
+		return f, false
+	}
+
+	return Failed{}, true
+}
+
+// issueLabelNames returns the label names of the issue, or nil when its labels are unknown.
+func issueLabelNames(issue *github.Issue) []string {
+	if issue == nil || issue.Labels == nil {
+		return nil
+	}
+	names := []string{}
+	for _, l := range issue.Labels {
+		names = append(names, l.GetName())
+	}
+	return names
+}
+
+// sameNames returns true if the names are the same regardless of order and case.
+func sameNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, s := range a {
+		if !includes(b, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// formatNames returns the names formatted for display.
+func formatNames(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
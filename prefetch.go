@@ -0,0 +1,96 @@
+package triage
+
+import (
+	"github.com/google/go-github/v28/github"
+	"github.com/tj/go-tea"
+)
+
+// prefetchCount is the number of notifications following the selection which are prefetched.
+var prefetchCount = 3
+
+// prefetchConcurrency is the maximum number of notifications prefetched at once.
+var prefetchConcurrency = 2
+
+// Details is the issue, labels, comments, and timeline events of a notification.
+type Details struct {
+	Issue       *github.Issue
+	StateReason string
+	Labels      []*github.Label
+	Comments    []*github.IssueComment
+	Events      []*github.Timeline
+}
+
+// prefetch returns a command loading the details of the selected notification
+// and those following it, skipping any prefetched or in flight already.
+func prefetch(m Model, items []listItem) (Model, tea.Cmd) {
+	var pending []*github.Notification
+
+	for i := m.Selected; i < len(items) && i <= m.Selected+prefetchCount; i++ {
+		n := items[i].Notification()
+		if n == nil || subjectIssue(n) == nil {
+			continue
+		}
+
+		if _, ok := m.Prefetched[notificationKey(n)]; ok || m.Prefetching[notificationKey(n)] {
+			continue
+		}
+
+		pending = append(pending, n)
+	}
+
+	if len(pending) == 0 {
+		return m, nil
+	}
+
+	prefetching := make(map[string]bool)
+	for key := range m.Prefetching {
+		prefetching[key] = true
+	}
+	for _, n := range pending {
+		prefetching[notificationKey(n)] = true
+	}
+	m.Prefetching = prefetching
+
+	return m, PrefetchNotifications(pending)
+}
+
+// prefetched records prefetched details, which are no longer in flight.
+func prefetched(m Model, msg NotificationsPrefetched) Model {
+	details := make(map[string]Details)
+	for key, d := range m.Prefetched {
+		details[key] = d
+	}
+	for key, d := range msg.Details {
+		details[key] = d
+	}
+	m.Prefetched = details
+
+	prefetching := make(map[string]bool)
+	for key := range m.Prefetching {
+		prefetching[key] = true
+	}
+	for _, key := range msg.Keys {
+		delete(prefetching, key)
+	}
+	m.Prefetching = prefetching
+
+	return m
+}
+
+// forgetPrefetched removes the prefetched details of notifications, which are stale once updated or opened.
+func forgetPrefetched(m Model, notifications []*github.Notification) Model {
+	if len(m.Prefetched) == 0 {
+		return m
+	}
+
+	details := make(map[string]Details)
+	for key, d := range m.Prefetched {
+		details[key] = d
+	}
+	for _, n := range notifications {
+		delete(details, notificationKey(n))
+	}
+	m.Prefetched = details
+
+	return m
+}
@@ -0,0 +1,303 @@
+package triage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/go-github/v28/github"
+)
+
+// Node is a query expression which matches notifications.
+type Node interface {
+	Match(n *github.Notification) bool
+}
+
+// And matches when all of its nodes match.
+type And []Node
+
+// Match implementation.
+func (a And) Match(n *github.Notification) bool {
+	for _, node := range a {
+		if !node.Match(n) {
+			return false
+		}
+	}
+	return true
+}
+
+// Not negates a node.
+type Not struct {
+	Node
+}
+
+// Match implementation.
+func (v Not) Match(n *github.Notification) bool {
+	return !v.Node.Match(n)
+}
+
+// Text matches free text against the subject title and repository name.
+type Text struct {
+	Value string
+}
+
+// Match implementation.
+func (t Text) Match(n *github.Notification) bool {
+	return contains(n.GetSubject().GetTitle(), t.Value) || contains(n.GetRepository().GetFullName(), t.Value)
+}
+
+// Field matches a qualifier such as repo:tj/triage or updated:<7d.
+type Field struct {
+	// Name is the qualifier name.
+	Name string
+
+	// Op is the comparison operator of updated qualifiers.
+	Op string
+
+	// Value is the qualifier value.
+	Value string
+
+	// Time is the resolved time of updated qualifiers.
+	Time time.Time
+}
+
+// Match implementation.
+func (f Field) Match(n *github.Notification) bool {
+	switch f.Name {
+	case "repo":
+		repo := n.GetRepository()
+		return equal(repo.GetFullName(), f.Value) || equal(repo.GetName(), f.Value)
+	case "owner", "org":
+		return equal(n.GetRepository().GetOwner().GetLogin(), f.Value)
+	case "reason":
+		return equal(n.GetReason(), f.Value)
+	case "type":
+		return equal(n.GetSubject().GetType(), subjectType(f.Value))
+	case "title":
+		return contains(n.GetSubject().GetTitle(), f.Value)
+	case "is":
+		switch strings.ToLower(f.Value) {
+		case "unread":
+			return n.GetUnread()
+		case "read":
+			return !n.GetUnread()
+		default:
+			return equal(n.GetSubject().GetType(), subjectType(f.Value))
+		}
+	case "updated":
+		updated := n.GetUpdatedAt()
+		switch f.Op {
+		case "<":
+			return updated.Before(f.Time)
+		case "<=":
+			return !updated.After(f.Time)
+		case ">":
+			return updated.After(f.Time)
+		case ">=":
+			return !updated.Before(f.Time)
+		default:
+			y1, m1, d1 := updated.Date()
+			y2, m2, d2 := f.Time.Date()
+			return y1 == y2 && m1 == m2 && d1 == d2
+		}
+	default:
+		return false
+	}
+}
+
+// subjectTypeAliases is a map of lowercase subject types and aliases to subject types.
+var subjectTypeAliases = map[string]string{
+	"pr":                           "PullRequest",
+	"pullrequest":                  "PullRequest",
+	"issue":                        "Issue",
+	"release":                      "Release",
+	"commit":                       "Commit",
+	"discussion":                   "Discussion",
+	"checksuite":                   "CheckSuite",
+	"repositoryvulnerabilityalert": "RepositoryVulnerabilityAlert",
+}
+
+// subjectType returns the subject type of an alias such as "pr", or the value itself.
+func subjectType(s string) string {
+	if v, ok := subjectTypeAliases[strings.ToLower(s)]; ok {
+		return v
+	}
+	return s
+}
+
+// fields is the set of supported qualifiers.
+var fields = map[string]bool{
+	"repo":    true,
+	"owner":   true,
+	"org":     true,
+	"reason":  true,
+	"type":    true,
+	"title":   true,
+	"is":      true,
+	"updated": true,
+}
+
+// ParseQuery parses a search query such as:
+//
+//	repo:tj/triage reason:mention -type:Release is:unread updated:<7d "crash on"
+//
+// Terms must all match, and may be negated with a leading "-". Free text
+// matches the subject title or repository name, and quoted text may contain
+// spaces. All matching is case-insensitive.
+//
+// The updated qualifier accepts a date such as 2020-01-30, or an age such
+// as 30m, 12h, 7d or 2w, where updated:<7d matches notifications updated
+// less than 7 days ago, and updated:<2020-01-30 those updated before the date.
+func ParseQuery(s string) (Node, error) {
+	return parseQuery(s, time.Now())
+}
+
+// parseQuery parses a query relative to the given time.
+func parseQuery(s string, now time.Time) (Node, error) {
+	var query And
+
+	for _, token := range tokenize(s) {
+		var negate bool
+		if len(token) > 1 && token[0] == '-' {
+			negate = true
+			token = token[1:]
+		}
+
+		node, err := parseTerm(token, now)
+		if err != nil {
+			return nil, err
+		}
+
+		if negate {
+			node = Not{node}
+		}
+
+		query = append(query, node)
+	}
+
+	return query, nil
+}
+
+// parseTerm parses a single qualifier or free text term.
+func parseTerm(s string, now time.Time) (Node, error) {
+	i := strings.Index(s, ":")
+
+	// free text
+	if i == -1 || strings.HasPrefix(s, `"`) {
+		return Text{unquote(s)}, nil
+	}
+
+	name := strings.ToLower(s[:i])
+	value := unquote(s[i+1:])
+
+	if !fields[name] {
+		return nil, fmt.Errorf("unsupported qualifier %q", name)
+	}
+
+	if value == "" {
+		return nil, fmt.Errorf("missing value for %q", name)
+	}
+
+	if name != "updated" {
+		return Field{Name: name, Value: value}, nil
+	}
+
+	// comparison
+	var op string
+	for _, o := range []string{"<=", ">=", "<", ">"} {
+		if strings.HasPrefix(value, o) {
+			op = o
+			value = value[len(o):]
+			break
+		}
+	}
+
+	t, err := parseTime(value, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// ages are the reverse of dates, updated less
+	// than 7 days ago is updated after 7 days ago
+	if _, err := time.Parse("2006-01-02", value); err != nil {
+		op = strings.NewReplacer("<", ">", ">", "<").Replace(op)
+	}
+
+	return Field{Name: name, Op: op, Value: value, Time: t}, nil
+}
+
+// parseTime parses a date such as 2020-01-30, or an age such as 7d relative to now.
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return t, nil
+	}
+
+	if len(s) < 2 {
+		return time.Time{}, fmt.Errorf("invalid date or age %q", s)
+	}
+
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date or age %q", s)
+	}
+
+	var unit time.Duration
+	switch s[len(s)-1] {
+	case 'm':
+		unit = time.Minute
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = time.Hour * 24
+	case 'w':
+		unit = time.Hour * 24 * 7
+	default:
+		return time.Time{}, fmt.Errorf("invalid age unit in %q", s)
+	}
+
+	return now.Add(-time.Duration(n) * unit), nil
+}
+
+// tokenize splits the query on whitespace, retaining quoted strings.
+func tokenize(s string) (tokens []string) {
+	var token strings.Builder
+	var quoted bool
+
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			token.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if token.Len() > 0 {
+				tokens = append(tokens, token.String())
+				token.Reset()
+			}
+		default:
+			token.WriteRune(r)
+		}
+	}
+
+	if token.Len() > 0 {
+		tokens = append(tokens, token.String())
+	}
+
+	return
+}
+
+// unquote removes surrounding quotes.
+func unquote(s string) string {
+	return strings.Trim(s, `"`)
+}
+
+// contains returns true if s contains substr, ignoring case.
+func contains(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// equal returns true if the strings are equal, ignoring case.
+func equal(a, b string) bool {
+	return strings.EqualFold(a, b)
+}
@@ -0,0 +1,164 @@
+package triage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+)
+
+func TestParseQuery(t *testing.T) {
+	now := time.Date(2020, 1, 30, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name  string
+		query string
+		node  Node
+	}{
+		{"empty", "", And(nil)},
+		{"text", "crash", And{Text{"crash"}}},
+		{"quoted text", `"crash on start"`, And{Text{"crash on start"}}},
+		{"qualifier", "repo:tj/triage", And{Field{Name: "repo", Value: "tj/triage"}}},
+		{"qualifier case", "Reason:mention", And{Field{Name: "reason", Value: "mention"}}},
+		{"quoted qualifier", `title:"crash on"`, And{Field{Name: "title", Value: "crash on"}}},
+		{"negated qualifier", "-type:Release", And{Not{Field{Name: "type", Value: "Release"}}}},
+		{"negated text", "-crash", And{Not{Text{"crash"}}}},
+		{"lone dash is text", "-", And{Text{"-"}}},
+		{"negation binds to its term", "-is:read crash", And{
+			Not{Field{Name: "is", Value: "read"}},
+			Text{"crash"},
+		}},
+		{"terms in order", `repo:tj/triage reason:mention -type:Release "crash on"`, And{
+			Field{Name: "repo", Value: "tj/triage"},
+			Field{Name: "reason", Value: "mention"},
+			Not{Field{Name: "type", Value: "Release"}},
+			Text{"crash on"},
+		}},
+		{"updated date", "updated:2020-01-01", And{Field{
+			Name:  "updated",
+			Value: "2020-01-01",
+			Time:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		}}},
+		{"updated before date", "updated:<2020-01-01", And{Field{
+			Name:  "updated",
+			Op:    "<",
+			Value: "2020-01-01",
+			Time:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		}}},
+		{"updated within age", "updated:<7d", And{Field{
+			Name:  "updated",
+			Op:    ">",
+			Value: "7d",
+			Time:  now.Add(-7 * 24 * time.Hour),
+		}}},
+		{"updated over age", "updated:>=2w", And{Field{
+			Name:  "updated",
+			Op:    "<=",
+			Value: "2w",
+			Time:  now.Add(-14 * 24 * time.Hour),
+		}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			node, err := parseQuery(c.query, now)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(node, c.node) {
+				t.Fatalf("expected %#v, got %#v", c.node, node)
+			}
+		})
+	}
+}
+
+func TestParseQuery_invalid(t *testing.T) {
+	cases := []struct {
+		name  string
+		query string
+		err   string
+	}{
+		{"unsupported qualifier", "label:bug", `unsupported qualifier "label"`},
+		{"missing value", "repo:", `missing value for "repo"`},
+		{"invalid age", "updated:<7x", `invalid age unit in "7x"`},
+		{"invalid number", "updated:<xd", `invalid date or age "xd"`},
+		{"invalid date", "updated:2020-13", `invalid date or age "2020-13"`},
+		{"short age", "updated:>d", `invalid date or age "d"`},
+		{"invalid term among valid", "repo:tj/triage label:bug", `unsupported qualifier "label"`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := parseQuery(c.query, time.Now())
+			if err == nil {
+				t.Fatalf("expected error %q", c.err)
+			}
+			if err.Error() != c.err {
+				t.Fatalf("expected error %q, got %q", c.err, err)
+			}
+		})
+	}
+}
+
+func TestQuery_Match(t *testing.T) {
+	now := time.Date(2020, 1, 30, 12, 0, 0, 0, time.UTC)
+	updated := now.Add(-48 * time.Hour)
+
+	n := &github.Notification{
+		Reason:    github.String("mention"),
+		Unread:    github.Bool(true),
+		UpdatedAt: &updated,
+		Subject: &github.NotificationSubject{
+			Title: github.String("Crash on start"),
+			Type:  github.String("PullRequest"),
+		},
+		Repository: &github.Repository{
+			Name:     github.String("triage"),
+			FullName: github.String("tj/triage"),
+			Owner:    &github.User{Login: github.String("tj")},
+		},
+	}
+
+	cases := []struct {
+		query string
+		match bool
+	}{
+		{"", true},
+		{"crash", true},
+		{"CRASH", true},
+		{"tj/triage", true},
+		{"-crash", false},
+		{"repo:triage", true},
+		{"repo:tj/triage", true},
+		{"repo:tj/other", false},
+		{"owner:tj", true},
+		{"reason:mention", true},
+		{"-reason:mention", false},
+		{"type:pr", true},
+		{"is:pr", true},
+		{"is:issue", false},
+		{"is:unread", true},
+		{"is:read", false},
+		{`title:"on start"`, true},
+		{"updated:<7d", true},
+		{"updated:<1d", false},
+		{"updated:2020-01-28", true},
+		{"updated:>2020-01-29", false},
+		{"repo:tj/triage crash", true},
+		{"repo:tj/triage -crash", false},
+		{"crash missing", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			node, err := parseQuery(c.query, now)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if match := node.Match(n); match != c.match {
+				t.Fatalf("expected match %v, got %v", c.match, match)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+)
+
+// rateLimitThreshold is the remaining quota at which requests are queued,
+// and spaced out over the time remaining until the limit resets.
+var rateLimitThreshold = 50
+
+// maxRateLimitWait is the longest a request is delayed for the rate limit to
+// reset, beyond which it is sent and allowed to fail.
+var maxRateLimitWait = time.Minute
+
+// maxRateLimitRetries is the maximum number of retries of rate limited requests.
+var maxRateLimitRetries = 3
+
+// RateLimitTransport is an http.RoundTripper which tracks the remaining API
+// quota, queues requests when near the limit, and retries requests which
+// were rate limited after the Retry-After or reset time.
+type RateLimitTransport struct {
+	// Base is the underlying transport.
+	Base http.RoundTripper
+
+	queue sync.Mutex
+
+	mu   sync.Mutex
+	rate github.Rate
+}
+
+// Rate returns the most recent rate limit, the zero value until a response is received.
+func (t *RateLimitTransport) Rate() github.Rate {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.rate
+}
+
+// RoundTrip implementation.
+func (t *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	for attempt := 0; ; attempt++ {
+		err := t.wait(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		r, err := rewind(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := t.Base.RoundTrip(r)
+		if err != nil {
+			return nil, err
+		}
+
+		t.update(res)
+
+		// retry when the delay fits within the request's deadline, otherwise
+		// the rate limit error is returned, and may be retried by the caller
+		delay, ok := rateLimitDelay(res)
+		if !ok || delay > maxRateLimitWait || !fits(ctx, delay) || attempt == maxRateLimitRetries {
+			return res, nil
+		}
+
+		res.Body.Close()
+
+		err = sleep(ctx, delay)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// wait delays the request when the remaining quota is low, one request at a time.
+func (t *RateLimitTransport) wait(ctx context.Context) error {
+	rate := t.Rate()
+	if rate.Limit == 0 || rate.Remaining > rateLimitThreshold {
+		return nil
+	}
+
+	t.queue.Lock()
+	defer t.queue.Unlock()
+
+	// re-check, as the rate may have changed while queued
+	rate = t.Rate()
+	until := time.Until(rate.Reset.Time)
+	if until <= 0 || rate.Remaining > rateLimitThreshold {
+		return nil
+	}
+
+	// exhausted with a long wait, let it fail
+	if rate.Remaining == 0 && until > maxRateLimitWait {
+		return nil
+	}
+
+	// spread the remaining quota over the time until reset
+	delay := until / time.Duration(rate.Remaining+1)
+	if !fits(ctx, delay) {
+		return nil
+	}
+
+	return sleep(ctx, delay)
+}
+
+// update the rate from the response headers.
+func (t *RateLimitTransport) update(res *http.Response) {
+	limit, err := strconv.Atoi(res.Header.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return
+	}
+
+	remaining, _ := strconv.Atoi(res.Header.Get("X-RateLimit-Remaining"))
+	reset, _ := strconv.ParseInt(res.Header.Get("X-RateLimit-Reset"), 10, 64)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rate = github.Rate{
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     github.Timestamp{Time: time.Unix(reset, 0)},
+	}
+}
+
+// rateLimitDelay returns the delay before retrying a rate limited response,
+// using Retry-After for secondary rate limits, or the reset time when the
+// quota is exhausted.
+func rateLimitDelay(res *http.Response) (time.Duration, bool) {
+	if res.StatusCode != http.StatusForbidden && res.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	// secondary rate limit
+	if v := res.Header.Get("Retry-After"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	// primary rate limit
+	if res.Header.Get("X-RateLimit-Remaining") == "0" {
+		reset, err := strconv.ParseInt(res.Header.Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		return time.Until(time.Unix(reset, 0)), true
+	}
+
+	return 0, false
+}
+
+// rateLimitRetry returns the delay before retrying an operation which failed
+// due to a rate limit, when the limit allows within maxRateLimitWait.
+func rateLimitRetry(err error) (time.Duration, bool) {
+	var abuse *github.AbuseRateLimitError
+	if errors.As(err, &abuse) {
+		if abuse.RetryAfter == nil {
+			return maxRateLimitWait, true
+		}
+		return *abuse.RetryAfter, *abuse.RetryAfter <= maxRateLimitWait
+	}
+
+	var limit *github.RateLimitError
+	if errors.As(err, &limit) {
+		delay := time.Until(limit.Rate.Reset.Time)
+		return delay, delay <= maxRateLimitWait
+	}
+
+	return 0, false
+}
+
+// fits returns true if the delay ends before the context's deadline.
+func fits(ctx context.Context, d time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Now().Add(d).Before(deadline)
+}
+
+// rewind returns the request for the given attempt, with a fresh body for retries.
+func rewind(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.Body == nil || req.GetBody == nil {
+		return req, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+
+	r := req.Clone(req.Context())
+	r.Body = body
+	return r, nil
+}
+
+// sleep for the duration, or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
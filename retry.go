@@ -0,0 +1,138 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// minBackoff is the delay before the first retry, doubling for each attempt.
+var minBackoff = time.Millisecond * 250
+
+// maxBackoff is the maximum delay between retries.
+var maxBackoff = time.Second * 5
+
+// RetryTransport is an http.RoundTripper which applies a timeout to each attempt,
+// and retries requests which fail with a server error, timeout, or connection
+// reset using exponential backoff with jitter. Client errors are never retried,
+// and POST requests are only retried when the connection could not be made,
+// as they are not idempotent.
+type RetryTransport struct {
+	// Base is the underlying transport.
+	Base http.RoundTripper
+
+	// Policy is the retry policy.
+	Policy Retry
+}
+
+// RoundTrip implementation.
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	for attempt := 0; ; attempt++ {
+		r, err := rewind(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := t.attempt(r)
+
+		// the caller's context is done, or out of attempts
+		if ctx.Err() != nil || attempt+1 >= t.Policy.Attempts {
+			return res, err
+		}
+
+		if !retryable(req.Method, res, err) {
+			return res, err
+		}
+
+		if res != nil {
+			res.Body.Close()
+		}
+
+		err = sleep(ctx, backoff(attempt))
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// attempt performs the request with the policy's timeout, which
+// remains in effect until the response body is closed.
+func (t *RetryTransport) attempt(req *http.Request) (*http.Response, error) {
+	if t.Policy.Timeout == 0 {
+		return t.Base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), time.Duration(t.Policy.Timeout))
+	res, err := t.Base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	res.Body = &cancelBody{res.Body, cancel}
+	return res, nil
+}
+
+// retryable returns true if the request should be retried.
+func retryable(method string, res *http.Response, err error) bool {
+	if err != nil {
+		if method == http.MethodPost {
+			return isDialError(err)
+		}
+		return isTransient(err)
+	}
+
+	return res.StatusCode >= 500 && method != http.MethodPost
+}
+
+// isTransient returns true for timeouts and connection resets.
+func isTransient(err error) bool {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return true
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNREFUSED):
+		return true
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return true
+	default:
+		return isDialError(err)
+	}
+}
+
+// isDialError returns true if the connection could not be made, so the request was not sent.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// backoff returns the delay before the next attempt, exponential with full jitter.
+func backoff(attempt int) time.Duration {
+	d := minBackoff << uint(attempt)
+	if d <= 0 || d > maxBackoff {
+		d = maxBackoff
+	}
+	return time.Duration(rand.Int63n(int64(d)) + 1)
+}
+
+// cancelBody is a response body which cancels its context when closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close implementation.
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,147 @@
+package triage
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v28/github"
+)
+
+// SortOrder is the order of the notifications list.
+type SortOrder string
+
+// Sort orders available.
+const (
+	SortUpdated    SortOrder = "updated"
+	SortRepository SortOrder = "repository"
+	SortReason     SortOrder = "reason"
+	SortPriority   SortOrder = "priority"
+	SortAge        SortOrder = "age"
+	SortComments   SortOrder = "comments"
+)
+
+// sortOrders is the order in which sort orders are toggled.
+var sortOrders = []SortOrder{
+	SortUpdated,
+	SortRepository,
+	SortReason,
+	SortPriority,
+	SortAge,
+	SortComments,
+}
+
+// Next returns the next sort order.
+func (o SortOrder) Next() SortOrder {
+	for i, v := range sortOrders {
+		if v == o {
+			return sortOrders[(i+1)%len(sortOrders)]
+		}
+	}
+	return SortUpdated
+}
+
+// NeedsIssues returns true if the order requires the notification issues.
+func (o SortOrder) NeedsIssues() bool {
+	switch o {
+	case SortPriority, SortAge, SortComments:
+		return true
+	default:
+		return false
+	}
+}
+
+// sortNotifications returns a copy of the notifications sorted by the given order,
+// falling back on the updated time. Issue related orders place notifications
+// without a loaded issue last.
+func sortNotifications(notifications []*github.Notification, order SortOrder, issues map[string]*github.Issue, priorities []Priority) []*github.Notification {
+	sorted := append([]*github.Notification{}, notifications...)
+
+	// priority ranks, where later priorities are more important
+	rank := func(n *github.Notification) int {
+		issue := issues[notificationKey(n)]
+		if issue == nil {
+			return -1
+		}
+		for i := len(priorities) - 1; i >= 0; i-- {
+			for _, l := range issue.Labels {
+				if l.GetName() == priorities[i].Label {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a := sorted[i]
+		b := sorted[j]
+
+		switch order {
+		case SortRepository:
+			if c := strings.Compare(a.GetRepository().GetFullName(), b.GetRepository().GetFullName()); c != 0 {
+				return c < 0
+			}
+		case SortReason:
+			if c := strings.Compare(a.GetReason(), b.GetReason()); c != 0 {
+				return c < 0
+			}
+		case SortPriority:
+			if ra, rb := rank(a), rank(b); ra != rb {
+				return ra > rb
+			}
+		case SortAge:
+			ia, ib := issues[notificationKey(a)], issues[notificationKey(b)]
+			if (ia == nil) != (ib == nil) {
+				return ia != nil
+			}
+			if ia != nil && !ia.GetCreatedAt().Equal(ib.GetCreatedAt()) {
+				return ia.GetCreatedAt().Before(ib.GetCreatedAt())
+			}
+		case SortComments:
+			ia, ib := issues[notificationKey(a)], issues[notificationKey(b)]
+			if (ia == nil) != (ib == nil) {
+				return ia != nil
+			}
+			if ia != nil && ia.GetComments() != ib.GetComments() {
+				return ia.GetComments() > ib.GetComments()
+			}
+		}
+
+		return a.GetUpdatedAt().After(b.GetUpdatedAt())
+	})
+
+	return sorted
+}
+
+// sortModel sorts the notifications by the model's sort order, retaining the selection.
+func sortModel(m Model, priorities []Priority) Model {
+	selected, ok := selectedItem(m)
+	m.Notifications = sortNotifications(m.Notifications, m.Sort, m.Issues, priorities)
+	if ok {
+		m = selectItem(m, selected)
+	}
+	return m
+}
+
+// changedIssues returns the polled notifications with an issue, which are new or updated.
+func changedIssues(m Model, polled []*github.Notification) (changed []*github.Notification) {
+	for _, n := range polled {
+		if subjectIssue(n) != nil && m.Highlighted[notificationKey(n)] {
+			changed = append(changed, n)
+		}
+	}
+	return
+}
+
+// missingIssues returns the notifications with an issue which has not been loaded.
+func missingIssues(m Model) (missing []*github.Notification) {
+	for _, n := range m.Notifications {
+		if subjectIssue(n) == nil {
+			continue
+		}
+		if _, ok := m.Issues[notificationKey(n)]; !ok {
+			missing = append(missing, n)
+		}
+	}
+	return
+}
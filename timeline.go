@@ -0,0 +1,135 @@
+package triage
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+)
+
+// ignoredEvents is the timeline events which are not displayed, as they
+// are displayed otherwise, or are noise.
+var ignoredEvents = []string{
+	"commented",
+	"committed",
+	"reviewed",
+	"mentioned",
+	"subscribed",
+	"unsubscribed",
+}
+
+// threadEntry is a comment or timeline event of a notification's thread.
+type threadEntry struct {
+	Comment *github.IssueComment
+	Event   *github.Timeline
+}
+
+// Time returns the time of the entry.
+func (e threadEntry) Time() time.Time {
+	if e.Comment != nil {
+		return e.Comment.GetCreatedAt()
+	}
+	return e.Event.GetCreatedAt()
+}
+
+// threadEntries returns the comments interleaved chronologically with the
+// displayable events, omitting events before since when it is non-zero.
+func threadEntries(comments []*github.IssueComment, events []*github.Timeline, since time.Time) (entries []threadEntry) {
+	for _, c := range comments {
+		entries = append(entries, threadEntry{Comment: c})
+	}
+
+	for _, e := range events {
+		if e.CreatedAt == nil || includes(ignoredEvents, e.GetEvent()) || e.GetCreatedAt().Before(since) {
+			continue
+		}
+		entries = append(entries, threadEntry{Event: e})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time().Before(entries[j].Time())
+	})
+
+	return
+}
+
+// eventText returns a description of the event, for example "added the bug label".
+func eventText(e *github.Timeline) string {
+	switch e.GetEvent() {
+	case "closed":
+		return "closed this"
+	case "reopened":
+		return "reopened this"
+	case "merged":
+		return "merged this"
+	case "locked":
+		return "locked this"
+	case "unlocked":
+		return "unlocked this"
+	case "labeled":
+		return fmt.Sprintf("added the %s label", e.GetLabel().GetName())
+	case "unlabeled":
+		return fmt.Sprintf("removed the %s label", e.GetLabel().GetName())
+	case "assigned":
+		if e.GetAssignee().GetLogin() == e.GetActor().GetLogin() {
+			return "self-assigned this"
+		}
+		return fmt.Sprintf("assigned @%s", e.GetAssignee().GetLogin())
+	case "unassigned":
+		if e.GetAssignee().GetLogin() == e.GetActor().GetLogin() {
+			return "removed their assignment"
+		}
+		return fmt.Sprintf("unassigned @%s", e.GetAssignee().GetLogin())
+	case "milestoned":
+		return fmt.Sprintf("added this to the %s milestone", e.GetMilestone().GetTitle())
+	case "demilestoned":
+		return fmt.Sprintf("removed this from the %s milestone", e.GetMilestone().GetTitle())
+	case "renamed":
+		return fmt.Sprintf("changed the title from %q to %q", e.GetRename().GetFrom(), e.GetRename().GetTo())
+	case "cross-referenced":
+		return fmt.Sprintf("mentioned this in %s", sourceName(e.GetSource()))
+	case "referenced":
+		return fmt.Sprintf("referenced this in commit %s", shortSHA(e.GetCommitID()))
+	case "head_ref_force_pushed":
+		return "force-pushed the branch"
+	case "head_ref_deleted":
+		return "deleted the branch"
+	case "head_ref_restored":
+		return "restored the branch"
+	case "ready_for_review":
+		return "marked this as ready for review"
+	case "convert_to_draft":
+		return "marked this as a draft"
+	case "review_requested":
+		return "requested a review"
+	case "marked_as_duplicate":
+		return "marked this as a duplicate"
+	default:
+		return strings.Replace(e.GetEvent(), "_", " ", -1)
+	}
+}
+
+// sourceName returns the name of a cross-reference's source, for example "tj/triage#5".
+func sourceName(s *github.Source) string {
+	issue := s.GetIssue()
+
+	repo := issue.GetRepository().GetFullName()
+	if repo == "" {
+		// the repository url ends in the owner and name
+		u := issue.GetRepositoryURL()
+		repo = path.Base(path.Dir(u)) + "/" + path.Base(u)
+	}
+
+	return fmt.Sprintf("%s#%d", repo, issue.GetNumber())
+}
+
+// shortSHA returns the abbreviated commit sha.
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
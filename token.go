@@ -0,0 +1,341 @@
+package triage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+)
+
+// errNotFound is returned by token sources which have no token for the host.
+var errNotFound = errors.New("not found")
+
+// Credential is the result of trying a token source.
+type Credential struct {
+	// Source is the name of the source, for example "GITHUB_TOKEN".
+	Source string
+
+	// Token is the token found, if any.
+	Token string
+
+	// Err is the reason a token was not found.
+	Err error
+}
+
+// tokenSource is a named source of tokens for a hostname.
+type tokenSource struct {
+	name  string
+	token func(ctx context.Context, hostname string) (string, error)
+}
+
+// DiscoverToken tries each token source for the host in order. The single
+// default account tries the environment variables, the gh CLI's hosts.yml,
+// git credential fill, netrc, and finally the token command when present.
+// Other accounts try their token command first, then the sources scoped to
+// their host, and the environment variables last and only for github.com, so
+// a github.com token is never sent to an Enterprise host. The attempts are
+// returned up to and including the first to find a token.
+func DiscoverToken(ctx context.Context, host Host, command string, defaultAccount bool) (attempts []Credential) {
+	env := []tokenSource{
+		{"GITHUB_TOKEN environment variable", envToken("GITHUB_TOKEN")},
+		{"GH_TOKEN environment variable", envToken("GH_TOKEN")},
+	}
+
+	scoped := []tokenSource{
+		{"gh hosts.yml", ghToken},
+		{"git credential fill", gitCredentialToken},
+		{"netrc", netrcToken},
+	}
+
+	var sources []tokenSource
+	if defaultAccount {
+		sources = append(env, scoped...)
+		if command != "" {
+			sources = append(sources, tokenSource{"token command", commandToken(command)})
+		}
+	} else {
+		if command != "" {
+			sources = append(sources, tokenSource{"token command", commandToken(command)})
+		}
+		sources = append(sources, scoped...)
+		if host.Hostname() == "github.com" {
+			sources = append(sources, env...)
+		}
+	}
+
+	for _, s := range sources {
+		token, err := s.token(ctx, host.Hostname())
+		attempts = append(attempts, Credential{
+			Source: s.name,
+			Token:  token,
+			Err:    err,
+		})
+
+		if err == nil {
+			return
+		}
+	}
+
+	return
+}
+
+// Hostname returns the web hostname of the host, for example "github.com".
+func (h Host) Hostname() string {
+	u, err := url.Parse(h.WebURL())
+	if err != nil {
+		return "github.com"
+	}
+	return u.Hostname()
+}
+
+// requiredScopes is the token scopes needed for all functionality to work.
+var requiredScopes = []string{"notifications", "repo"}
+
+// TokenScopes returns the OAuth scopes of the client's token, or nil for
+// tokens which do not report scopes, such as fine-grained tokens.
+func TokenScopes(ctx context.Context, gh *github.Client) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	req, err := gh.NewRequest("GET", "user", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := gh.Do(ctx, req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetching user: %w", err)
+	}
+
+	header := res.Header.Get("X-OAuth-Scopes")
+	if header == "" {
+		return nil, nil
+	}
+
+	var scopes []string
+	for _, s := range strings.Split(header, ",") {
+		scopes = append(scopes, strings.TrimSpace(s))
+	}
+
+	return scopes, nil
+}
+
+// MissingScopes returns the required scopes which are missing. The repo
+// scope includes access to notifications, so it satisfies both.
+func MissingScopes(scopes []string) (missing []string) {
+	has := make(map[string]bool)
+	for _, s := range scopes {
+		has[s] = true
+	}
+
+	for _, s := range requiredScopes {
+		if has[s] || (s == "notifications" && has["repo"]) {
+			continue
+		}
+		missing = append(missing, s)
+	}
+
+	return
+}
+
+// envToken returns a source reading the environment variable.
+func envToken(name string) func(context.Context, string) (string, error) {
+	return func(context.Context, string) (string, error) {
+		if v := os.Getenv(name); v != "" {
+			return v, nil
+		}
+		return "", errors.New("not set")
+	}
+}
+
+// ghToken returns the token of the gh CLI's hosts.yml.
+func ghToken(ctx context.Context, hostname string) (string, error) {
+	dir := os.Getenv("GH_CONFIG_DIR")
+
+	if dir == "" {
+		if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+			dir = filepath.Join(xdg, "gh")
+		}
+	}
+
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config", "gh")
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "hosts.yml"))
+	if os.IsNotExist(err) {
+		return "", errNotFound
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return parseGhHosts(b, hostname)
+}
+
+// parseGhHosts returns the oauth_token of the host in a gh hosts.yml file,
+// which is a mapping of hostnames to their settings, for example:
+//
+//	github.com:
+//	    user: tj
+//	    oauth_token: 1234567c5560a274c59541e0787bf12345abcdef
+func parseGhHosts(b []byte, hostname string) (string, error) {
+	var inHost bool
+
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line := s.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		// hostname
+		if line[0] != ' ' && line[0] != '\t' {
+			inHost = strings.TrimSuffix(trimmed, ":") == hostname
+			continue
+		}
+
+		// setting
+		if inHost && strings.HasPrefix(trimmed, "oauth_token:") {
+			token := strings.TrimSpace(strings.TrimPrefix(trimmed, "oauth_token:"))
+			token = strings.Trim(token, `"'`)
+			if token != "" {
+				return token, nil
+			}
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+
+	return "", errNotFound
+}
+
+// gitCredentialToken returns the password of git's credential helper for the host.
+func gitCredentialToken(ctx context.Context, hostname string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "credential", "fill")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("protocol=https\nhost=%s\n\n", hostname))
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=")
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errNotFound
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "password=") {
+			if token := strings.TrimPrefix(line, "password="); token != "" {
+				return token, nil
+			}
+		}
+	}
+
+	return "", errNotFound
+}
+
+// netrcToken returns the password of the host's netrc entry.
+func netrcToken(ctx context.Context, hostname string) (string, error) {
+	path := os.Getenv("NETRC")
+
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, ".netrc")
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", errNotFound
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	// github.com tokens may be stored for the api hostname
+	hostnames := []string{hostname}
+	if hostname == "github.com" {
+		hostnames = append(hostnames, "api.github.com")
+	}
+
+	for _, h := range hostnames {
+		if token := parseNetrc(b, h); token != "" {
+			return token, nil
+		}
+	}
+
+	return "", errNotFound
+}
+
+// parseNetrc returns the password of the machine in a netrc file.
+func parseNetrc(b []byte, machine string) string {
+	fields := strings.Fields(string(b))
+
+	var current string
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "machine":
+			if i+1 < len(fields) {
+				i++
+				current = fields[i]
+			}
+		case "default":
+			current = ""
+		case "password":
+			if i+1 < len(fields) {
+				i++
+				if current == machine {
+					return fields[i]
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// commandToken returns a source which runs the shell command, using its output as the token.
+func commandToken(command string) func(context.Context, string) (string, error) {
+	return func(ctx context.Context, hostname string) (string, error) {
+		ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+		defer cancel()
+
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Stderr = os.Stderr
+
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("running %q: %w", command, err)
+		}
+
+		token := strings.TrimSpace(string(out))
+		if token == "" {
+			return "", fmt.Errorf("%q produced no output", command)
+		}
+
+		return token, nil
+	}
+}
@@ -0,0 +1,79 @@
+package triage
+
+import (
+	"testing"
+)
+
+func TestParseGhHosts(t *testing.T) {
+	hosts := `github.com:
+    user: tj
+    oauth_token: abc123
+    git_protocol: ssh
+github.example.com:
+    user: tj
+    oauth_token: "def456"
+# comment
+empty.example.com:
+    user: tj
+`
+
+	cases := []struct {
+		name     string
+		hosts    string
+		hostname string
+		token    string
+		err      error
+	}{
+		{"github.com", hosts, "github.com", "abc123", nil},
+		{"enterprise quoted", hosts, "github.example.com", "def456", nil},
+		{"host without token", hosts, "empty.example.com", "", errNotFound},
+		{"missing host", hosts, "other.example.com", "", errNotFound},
+		{"empty file", "", "github.com", "", errNotFound},
+		{"token of another host", "github.example.com:\n    oauth_token: def456\n", "github.com", "", errNotFound},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			token, err := parseGhHosts([]byte(c.hosts), c.hostname)
+			if err != c.err {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+			if token != c.token {
+				t.Fatalf("expected token %q, got %q", c.token, token)
+			}
+		})
+	}
+}
+
+func TestParseNetrc(t *testing.T) {
+	netrc := `machine api.github.com
+  login tj
+  password abc123
+
+machine github.example.com login tj password def456
+default login anonymous password ghi789
+`
+
+	cases := []struct {
+		name    string
+		netrc   string
+		machine string
+		token   string
+	}{
+		{"multi-line entry", netrc, "api.github.com", "abc123"},
+		{"single-line entry", netrc, "github.example.com", "def456"},
+		{"default is not used", netrc, "other.example.com", ""},
+		{"missing machine", "machine api.github.com password abc123", "github.com", ""},
+		{"missing password", "machine github.com login tj", "github.com", ""},
+		{"empty file", "", "github.com", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			token := parseNetrc([]byte(c.netrc), c.machine)
+			if token != c.token {
+				t.Fatalf("expected token %q, got %q", c.token, token)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package triage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/tj/go-tea"
+)
+
+// undoTTL is how long an action may be undone.
+var undoTTL = time.Second * 30
+
+// Undo is a triage action which may be reverted.
+type Undo struct {
+	// Op is the operation performed.
+	Op Op
+
+	// Removed is the notifications removed from the list.
+	Removed []Removed
+
+	// Repos is a notification of each repository unwatched.
+	Repos []*github.Notification
+
+	// Notification is the notification which was labeled or prioritized.
+	Notification *github.Notification
+
+	// Issue is the issue which was labeled or prioritized.
+	Issue *github.Issue
+
+	// Labels is the previous issue labels, never nil as
+	// restoring an empty list removes every label.
+	Labels []string
+
+	// Time is when the action was performed.
+	Time time.Time
+}
+
+// Removed is a notification removed from the list.
+type Removed struct {
+	// Notification removed.
+	Notification *github.Notification
+
+	// Index is the previous position in the list.
+	Index int
+}
+
+// pushUndo adds an undo, pruning those which have expired.
+func pushUndo(m Model, u Undo) Model {
+	u.Time = time.Now()
+	m.UndoStack = append(pruneUndo(m.UndoStack), u)
+	return m
+}
+
+// pruneUndo returns the undo stack without expired entries.
+func pruneUndo(stack []Undo) (pruned []Undo) {
+	for _, u := range stack {
+		if time.Since(u.Time) < undoTTL {
+			pruned = append(pruned, u)
+		}
+	}
+	return
+}
+
+// undo reverts the most recent action.
+func undo(m Model) (Model, tea.Cmd) {
+	m.UndoStack = pruneUndo(m.UndoStack)
+	if len(m.UndoStack) == 0 {
+		m.Notice = "Nothing to undo"
+		m.NoticeID++
+		return m, ExpireNotice(m.NoticeID)
+	}
+
+	u := m.UndoStack[len(m.UndoStack)-1]
+	m.UndoStack = m.UndoStack[:len(m.UndoStack)-1]
+	m = restoreNotifications(m, u.Removed)
+
+	var cmds []tea.Cmd
+	switch u.Op {
+	case OpMarkAsRead:
+		// the API cannot mark threads as unread, so they are only restored to the list
+		m.Notice = fmt.Sprintf("Restored %d notifications locally, they remain read on GitHub", len(u.Removed))
+	case OpUnsubscribe:
+		m.Notice = fmt.Sprintf("Resubscribed to %d notifications", len(u.Removed))
+		for _, r := range u.Removed {
+			cmds = append(cmds, Resubscribe(r.Notification))
+		}
+	case OpUnwatch:
+		m.Notice = fmt.Sprintf("Watching %d repositories again", len(u.Repos))
+		for _, n := range u.Repos {
+			cmds = append(cmds, Rewatch(n))
+		}
+	case OpUpdateLabels, OpUpdatePriority:
+		if u.Issue == nil || u.Labels == nil {
+			m.Notice = "Nothing to undo"
+			break
+		}
+
+		m.Notice = "Restored labels"
+
+		// the labels displayed are the base of the restore, when the issue is displayed
+		issue := *u.Issue
+		issue.Labels = nil
+		if sameNotification(m.Notification, u.Notification) && m.Issue != nil {
+			issue = *shownIssue(m)
+			m.LoadingLabels = true
+		}
+
+		cmds = append(cmds, UpdateNotificationLabels(u.Notification, &issue, u.Labels))
+	}
+
+	m.NoticeID++
+	cmds = append(cmds, ExpireNotice(m.NoticeID))
+	return m, tea.Batch(cmds...)
+}
+
+// undoFailed reverts the removal of a notification when its action has failed.
+func undoFailed(m Model, n *github.Notification) Model {
+	for i, u := range m.UndoStack {
+		for j, r := range u.Removed {
+			if notificationKey(r.Notification) != notificationKey(n) {
+				continue
+			}
+			m = restoreNotifications(m, []Removed{r})
+			u.Removed = append(u.Removed[:j:j], u.Removed[j+1:]...)
+			m.UndoStack[i] = u
+			return m
+		}
+	}
+	return m
+}
+
+// markAsRead optimistically marks the notifications as read.
+func markAsRead(m Model, notifications []*github.Notification) (Model, tea.Cmd) {
+	m.Checked = nil
+	m, removed := removeNotifications(m, notifications)
+	m = pushUndo(m, Undo{
+		Op:      OpMarkAsRead,
+		Removed: removed,
+	})
+
+	var cmds []tea.Cmd
+	for _, n := range notifications {
+		cmds = append(cmds, MarkAsRead(n))
+	}
+
+	if len(cmds) == 1 {
+		return m, cmds[0]
+	}
+
+	return startBatch(m, "Marking as read", cmds)
+}
+
+// unsubscribe optimistically unsubscribes from the notifications.
+func unsubscribe(m Model, notifications []*github.Notification) (Model, tea.Cmd) {
+	m.Checked = nil
+	m, removed := removeNotifications(m, notifications)
+	m = pushUndo(m, Undo{
+		Op:      OpUnsubscribe,
+		Removed: removed,
+	})
+
+	var cmds []tea.Cmd
+	for _, n := range notifications {
+		cmds = append(cmds, Unsubscribe(n))
+	}
+
+	if len(cmds) == 1 {
+		return m, cmds[0]
+	}
+
+	return startBatch(m, "Unsubscribing", cmds)
+}
+
+// unwatch optimistically unwatches the repositories of the notifications,
+// marking all of their notifications as read.
+func unwatch(m Model, notifications []*github.Notification) (Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	var repos []*github.Notification
+	var read []*github.Notification
+
+	seen := make(map[string]bool)
+	for _, n := range notifications {
+		owner, repo := ownerRepo(n)
+		name := owner + "/" + repo
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		repos = append(repos, n)
+		cmds = append(cmds, Unwatch(n))
+		for _, n := range getNotificationsByRepo(m.Notifications, owner, repo) {
+			cmds = append(cmds, MarkAsRead(n))
+			read = append(read, n)
+		}
+	}
+
+	m.Checked = nil
+	m, removed := removeNotifications(m, read)
+	m = pushUndo(m, Undo{
+		Op:      OpUnwatch,
+		Removed: removed,
+		Repos:   repos,
+	})
+
+	return startBatch(m, "Unwatching", cmds)
+}
+
+// removeNotifications removes notifications from the list, returning their previous positions.
+func removeNotifications(m Model, notifications []*github.Notification) (Model, []Removed) {
+	var removed []Removed
+
+	for _, n := range notifications {
+		i := getNotificationIndex(m.Notifications, notificationKey(n))
+		if i == -1 {
+			continue
+		}
+		removed = append(removed, Removed{n, i})
+		m.Notifications = removeNotification(m.Notifications, notificationKey(n))
+	}
+
+	m.Selected = clampSelected(m)
+	return m, removed
+}
+
+// restoreNotifications inserts notifications back into the list at their previous positions.
+func restoreNotifications(m Model, removed []Removed) Model {
+	if len(removed) == 0 {
+		return m
+	}
+
+	// restore in the reverse order of removal
+	// so that the indexes remain valid
+	notifications := append([]*github.Notification{}, m.Notifications...)
+	for i := len(removed) - 1; i >= 0; i-- {
+		r := removed[i]
+		if getNotificationIndex(notifications, notificationKey(r.Notification)) != -1 {
+			continue
+		}
+		index := min(r.Index, len(notifications))
+		notifications = append(notifications[:index], append([]*github.Notification{r.Notification}, notifications[index:]...)...)
+	}
+	m.Notifications = notifications
+
+	// select the first restored notification
+	items := listItems(m, visibleNotifications(m))
+	if i := getNotificationItemIndex(items, notificationKey(removed[0].Notification)); i != -1 {
+		m.Selected = i
+		m.NotificationsScrollY = scrollNotifications(m, items, 1)
+	}
+
+	return m
+}
@@ -2,6 +2,9 @@ package triage
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/tj/go-tea/input"
 	"github.com/tj/go-tea/option"
@@ -12,6 +15,15 @@ import (
 	"github.com/tj/go-terminput"
 )
 
+// reviewEvents are the review events, mapping to the review options.
+var reviewEvents = []string{"APPROVE", "REQUEST_CHANGES", "COMMENT"}
+
+// closeReasons are the issue state reasons, mapping to the close options.
+var closeReasons = []string{"completed", "not_planned", "duplicate"}
+
+// lockReasons are the issue lock reasons, mapping to the lock options.
+var lockReasons = []string{"", "off-topic", "too heated", "resolved", "spam"}
+
 // listItemHeight is the number of rows a list item consumes.
 var listItemHeight = 4
 
@@ -30,9 +42,24 @@ type NotificationPriorityUpdated struct{}
 // CommentAdded msg.
 type CommentAdded struct{}
 
+// IssueStateUpdated msg.
+type IssueStateUpdated struct {
+	Notification *github.Notification
+	Issue        *github.Issue
+	StateReason  string
+	Commented    bool
+}
+
+// IssueLockUpdated msg.
+type IssueLockUpdated struct {
+	Notification *github.Notification
+	Issue        *github.Issue
+}
+
 // LabelsLoaded msg.
 type LabelsLoaded struct {
 	Labels []*github.Label
+	Cached bool
 }
 
 // NotificationsLoaded msg.
@@ -40,21 +67,154 @@ type NotificationsLoaded struct {
 	Notifications []*github.Notification
 }
 
+// NotificationsPageLoaded msg.
+type NotificationsPageLoaded struct {
+	Notifications []*github.Notification
+	Page          int
+	Next          []string
+	LastModified  map[string]string
+	PollInterval  time.Duration
+	Offline       bool
+	Failed        []Failed
+}
+
+// NotificationsPolled msg.
+type NotificationsPolled struct {
+	ID            int
+	Notifications []*github.Notification
+	LastModified  map[string]string
+	PollInterval  time.Duration
+	Err           error
+	Offline       bool
+}
+
+// Queued msg.
+type Queued struct {
+	Change  Change
+	Pending int
+}
+
+// OutboxReplayed msg.
+type OutboxReplayed struct {
+	Sent    int
+	Read    []string
+	Failed  []Failed
+	Pending int
+	Offline bool
+}
+
+// Failed msg.
+type Failed struct {
+	Op           Op
+	Notification *github.Notification
+	Err          error
+	Time         time.Time
+}
+
+// ToastExpired msg.
+type ToastExpired struct {
+	ID int
+}
+
+// NoticeExpired msg.
+type NoticeExpired struct {
+	ID int
+}
+
+// BatchStepped msg.
+type BatchStepped struct {
+	ID      int
+	Msg     tea.Msg
+	Cmd     tea.Cmd
+	Attempt int
+}
+
+// HighlightsExpired msg.
+type HighlightsExpired struct {
+	ID int
+}
+
 // NotificationIssueLoaded msg.
 type NotificationIssueLoaded struct {
-	Issue *github.Issue
+	Notification *github.Notification
+	Issue        *github.Issue
+	StateReason  string
+	Cached       bool
+}
+
+// NotificationPullRequestLoaded msg.
+type NotificationPullRequestLoaded struct {
+	Notification *github.Notification
+	PullRequest  *github.PullRequest
+}
+
+// NotificationReviewsLoaded msg.
+type NotificationReviewsLoaded struct {
+	Notification *github.Notification
+	Reviews      []*github.PullRequestReview
+}
+
+// NotificationChecksLoaded msg.
+type NotificationChecksLoaded struct {
+	Notification *github.Notification
+	CheckRuns    []*github.CheckRun
+	Status       *github.CombinedStatus
+}
+
+// PullRequestFilesLoaded msg.
+type PullRequestFilesLoaded struct {
+	Notification *github.Notification
+	Files        []*github.CommitFile
+}
+
+// NotificationsIssuesLoaded msg.
+type NotificationsIssuesLoaded struct {
+	Issues map[string]*github.Issue
+	Failed *Failed
+}
+
+// NotificationsPrefetched msg.
+type NotificationsPrefetched struct {
+	Details map[string]Details
+	Keys    []string
+}
+
+// AssigneesLoaded msg.
+type AssigneesLoaded struct {
+	Users  []*github.User
+	Cached bool
+}
+
+// AssigneesUpdated msg.
+type AssigneesUpdated struct {
+	Notification *github.Notification
+	Issue        *github.Issue
 }
 
 // NotificationLabelsLoaded msg.
 type NotificationLabelsLoaded struct {
-	Labels []*github.Label
+	Notification *github.Notification
+	Labels       []*github.Label
+	Cached       bool
 }
 
 // NotificationCommentsLoaded msg.
 type NotificationCommentsLoaded struct {
-	Comments []*github.IssueComment
+	Notification *github.Notification
+	Comments     []*github.IssueComment
+	Cached       bool
+}
+
+// NotificationEventsLoaded msg.
+type NotificationEventsLoaded struct {
+	Notification *github.Notification
+	Events       []*github.Timeline
+	Cached       bool
 }
 
+// ReviewSubmitted msg.
+type ReviewSubmitted struct{}
+
 // MarkedAsRead msg.
 type MarkedAsRead struct {
 	*github.Notification
@@ -76,9 +236,15 @@ func Update(ctx context.Context, msg tea.Msg, model tea.Model) (tea.Model, tea.C
 	m := model.(Model)
 	config := MustConfigFromContext(ctx)
 
+	// api quota shown in the menu
+	if clients, ok := ClientsFromContext(ctx); ok {
+		m.Rate, _ = clients.Rate()
+	}
+
 	// filter so that selection calculations
 	// take the search text into account
-	notifications := filterNotifications(m.Notifications, m.SearchInput.Value)
+	notifications := visibleNotifications(m)
+	items := listItems(m, notifications)
 
 	// dimensions
 	if v, ok := msg.(GotDimensions); ok {
@@ -87,6 +253,218 @@ func Update(ctx context.Context, msg tea.Msg, model tea.Model) (tea.Model, tea.C
 		return m, LoadNotifications
 	}
 
+	// polling
+	switch msg := msg.(type) {
+	case NotificationsPolled:
+		if msg.ID != m.PollID {
+			return m, nil
+		}
+		m.LastModified = msg.LastModified
+		m.PollInterval = msg.PollInterval
+		m.Offline = msg.Offline
+		m.PollID++
+		// errors are recorded without a toast, as the next poll may succeed,
+		// and other accounts may have results. Being offline is shown in the menu
+		if msg.Err != nil && !msg.Offline {
+			m.Errors = append(m.Errors, Failed{
+				Op:   OpPollNotifications,
+				Err:  msg.Err,
+				Time: time.Now(),
+			})
+		}
+		m, sendQueued := replayOutbox(m)
+		cmds := []tea.Cmd{PollNotifications(m.PollID, m.LastModified, m.PollInterval), sendQueued}
+		if len(msg.Notifications) == 0 {
+			return m, tea.Batch(cmds...)
+		}
+		m = forgetPrefetched(m, msg.Notifications)
+		m = mergePolled(m, items, msg.Notifications, config.Priorities)
+		cmds = append(cmds, ExpireHighlights(m.PollID))
+		if changed := changedIssues(m, msg.Notifications); m.Sort.NeedsIssues() && len(changed) > 0 {
+			cmds = append(cmds, LoadNotificationsIssues(changed))
+		}
+		return m, tea.Batch(cmds...)
+	case HighlightsExpired:
+		if msg.ID == m.PollID {
+			m.Highlighted = nil
+		}
+		return m, nil
+	}
+
+	// prefetching
+	if msg, ok := msg.(NotificationsPrefetched); ok {
+		return prefetched(m, msg), nil
+	}
+
+	// sorting
+	if msg, ok := msg.(NotificationsIssuesLoaded); ok {
+		issues := make(map[string]*github.Issue)
+		for k, v := range m.Issues {
+			issues[k] = v
+		}
+		for k, v := range msg.Issues {
+			issues[k] = v
+		}
+		m.Issues = issues
+		// recorded without a toast, as sorting falls back on the notifications alone
+		if msg.Failed != nil {
+			m = failed(m, *msg.Failed)
+		}
+		return sortModel(m, config.Priorities), nil
+	}
+
+	// loading, handled on every page so that pagination
+	// continues while viewing the errors or a notification
+	switch msg := msg.(type) {
+	case NotificationsPageLoaded:
+		if msg.Page == 1 {
+			m.PendingNotifications = nil
+			m.LastModified = msg.LastModified
+			m.PollInterval = msg.PollInterval
+			m.Offline = false
+		}
+		m.Offline = m.Offline || msg.Offline
+		m.PendingNotifications = append(m.PendingNotifications, msg.Notifications...)
+
+		// accounts which failed, listed without their notifications
+		var notice tea.Cmd
+		if len(msg.Failed) > 0 {
+			m.Errors = append(m.Errors, msg.Failed...)
+			m.Notice = fmt.Sprintf("Failed to load the notifications of %d accounts (press e for details)", len(msg.Failed))
+			m.NoticeID++
+			notice = ExpireNotice(m.NoticeID)
+		}
+
+		// next page of the accounts which have more
+		if len(msg.Next) > 0 && (config.MaxPages < 0 || msg.Page < config.MaxPages) {
+			m.LoadingPage = msg.Page + 1
+			return m, tea.Batch(LoadNotificationsPage(msg.Page+1, msg.Next), notice)
+		}
+
+		// done
+		notifications := m.PendingNotifications
+		m.PendingNotifications = nil
+		m.LoadingPage = 0
+		return m, tea.Batch(func(context.Context) tea.Msg {
+			return NotificationsLoaded{notifications}
+		}, notice)
+	case NotificationsLoaded:
+		m.Notifications = msg.Notifications
+		m.Loading = false
+		m = sortModel(m, config.Priorities)
+		m.PollID++
+		m, sendQueued := replayOutbox(m)
+		m, prefetchNext := prefetch(m, listItems(m, visibleNotifications(m)))
+		cmds := []tea.Cmd{PollNotifications(m.PollID, m.LastModified, m.PollInterval), sendQueued, prefetchNext}
+		if missing := missingIssues(m); m.Sort.NeedsIssues() && len(missing) > 0 {
+			cmds = append(cmds, LoadNotificationsIssues(missing))
+		}
+		return m, tea.Batch(cmds...)
+	}
+
+	// offline changes
+	switch msg := msg.(type) {
+	case Queued:
+		m.Offline = true
+		m.Queued = msg.Pending
+		m = applyQueued(m, msg.Change, config.Priorities)
+		m.Notice = fmt.Sprintf("Offline, %d changes queued", msg.Pending)
+		m.NoticeID++
+		return m, ExpireNotice(m.NoticeID)
+	case OutboxReplayed:
+		m.Replaying = false
+		m.Queued = msg.Pending
+		m.Offline = msg.Offline
+		m = removeRead(m, msg.Read)
+		for _, f := range msg.Failed {
+			m = failed(m, f)
+		}
+		m.Notice = fmt.Sprintf("Sent %d queued changes", msg.Sent)
+		if len(msg.Failed) > 0 {
+			m.Notice += fmt.Sprintf(", %d failed (press e for details)", len(msg.Failed))
+		}
+		m.NoticeID++
+		return m, ExpireNotice(m.NoticeID)
+	}
+
+	// errors
+	switch msg := msg.(type) {
+	case Failed:
+		m = failed(m, msg)
+		m.Toast = true
+		m.ToastID++
+		return m, ExpireToast(m.ToastID)
+	case ToastExpired:
+		if msg.ID == m.ToastID {
+			m.Toast = false
+		}
+		return m, nil
+	case NoticeExpired:
+		if msg.ID == m.NoticeID {
+			m.Notice = ""
+		}
+		return m, nil
+	}
+
+	// batch
+	if v, ok := msg.(BatchStepped); ok {
+		// rate limited, retry after the limit allows
+		if f, ok := v.Msg.(Failed); ok && v.Attempt < maxRateLimitRetries {
+			if delay, ok := rateLimitRetry(f.Err); ok {
+				return m, retryBatchStep(v.ID, v.Cmd, v.Attempt+1, delay)
+			}
+		}
+
+		var cmd tea.Cmd
+		current := v.ID == m.Batch.ID
+
+		if f, ok := v.Msg.(Failed); ok {
+			m = failed(m, f)
+			if current {
+				m.Batch.Failed++
+			}
+		} else {
+			model, c := Update(ctx, v.Msg, m)
+			m, cmd = model.(Model), c
+		}
+
+		// in flight when the next batch started, which carried over only its pending commands
+		if !current {
+			return m, cmd
+		}
+
+		m.Batch.Done++
+
+		// completed
+		if !m.Batch.Running() {
+			m.Notice = batchSummary(m.Batch)
+			m.NoticeID++
+			return m, tea.Batch(cmd, ExpireNotice(m.NoticeID))
+		}
+
+		m, next := nextBatchStep(m)
+		return m, tea.Batch(cmd, next)
+	}
+
+	// errors page
+	if m.Page == PageErrors {
+		switch msg := msg.(type) {
+		case *terminput.KeyboardInput:
+			switch msg.Key() {
+			case terminput.KeyEscape, terminput.KeyLeft:
+				m.Page = m.ErrorsReturn
+				m.ErrorsScrollY = 0
+				return m, nil
+			case terminput.KeyUp:
+				m.ErrorsScrollY = max(0, m.ErrorsScrollY-m.Height/4)
+				return m, nil
+			case terminput.KeyDown:
+				m.ErrorsScrollY += m.Height / 4
+				return m, nil
+			}
+		}
+	}
+
 	// comment
 	if m.Page == PageComment {
 		switch msg := msg.(type) {
@@ -108,14 +486,183 @@ func Update(ctx context.Context, msg tea.Msg, model tea.Model) (tea.Model, tea.C
 		}
 	}
 
+	// review
+	if m.Page == PageReview {
+		switch msg := msg.(type) {
+		case *terminput.KeyboardInput:
+			// event
+			if m.ReviewEvent == "" {
+				switch msg.Key() {
+				case terminput.KeyEscape:
+					m.Page = PageNotification
+					return m, nil
+				case terminput.KeyEnter:
+					m.ReviewEvent = reviewEvents[m.ReviewOptions.Selected]
+					return m, nil
+				default:
+					m.ReviewOptions = option.Update(msg, m.ReviewOptions)
+				}
+				return m, nil
+			}
+
+			// body
+			switch msg.Key() {
+			case terminput.KeyEscape:
+				m.ReviewInput = input.Model{}
+				m.Page = PageNotification
+				return m, nil
+			case terminput.KeyEnter:
+				body := m.ReviewInput.Value
+
+				// GitHub rejects change requests and comments without a body
+				if m.ReviewEvent != "APPROVE" && strings.TrimSpace(body) == "" {
+					m.Notice = "A comment is required for this review"
+					m.NoticeID++
+					return m, ExpireNotice(m.NoticeID)
+				}
+
+				m.ReviewInput = input.Model{}
+				m.Page = PageNotification
+				return m, SubmitReview(m.Notification, m.PullRequest, m.ReviewEvent, body)
+			default:
+				m.ReviewInput = input.Update(msg, m.ReviewInput)
+			}
+			return m, nil
+		}
+	}
+
+	// close
+	if m.Page == PageClose {
+		switch msg := msg.(type) {
+		case *terminput.KeyboardInput:
+			// reason, pull requests are closed without one
+			if m.CloseReason == "" && !isPullRequest(m.Notification) {
+				switch msg.Key() {
+				case terminput.KeyEscape:
+					m.Page = PageNotification
+					return m, nil
+				case terminput.KeyEnter:
+					m.CloseReason = closeReasons[m.CloseOptions.Selected]
+					return m, nil
+				default:
+					m.CloseOptions = option.Update(msg, m.CloseOptions)
+				}
+				return m, nil
+			}
+
+			// comment
+			switch msg.Key() {
+			case terminput.KeyEscape:
+				m.CloseInput = input.Model{}
+				m.Page = PageNotification
+				return m, nil
+			case terminput.KeyEnter:
+				comment := m.CloseInput.Value
+				m.CloseInput = input.Model{}
+				m.Page = PageNotification
+				m.PendingState = "Closing"
+				return m, CloseIssue(m.Notification, m.Issue, m.CloseReason, comment)
+			default:
+				m.CloseInput = input.Update(msg, m.CloseInput)
+			}
+			return m, nil
+		}
+	}
+
+	// lock
+	if m.Page == PageLock {
+		switch msg := msg.(type) {
+		case *terminput.KeyboardInput:
+			switch msg.Key() {
+			case terminput.KeyEscape:
+				m.Page = PageNotification
+				return m, nil
+			case terminput.KeyEnter:
+				m.Page = PageNotification
+				m.PendingState = "Locking"
+				return m, LockIssue(m.Notification, m.Issue, lockReasons[m.LockOptions.Selected])
+			default:
+				m.LockOptions = option.Update(msg, m.LockOptions)
+			}
+			return m, nil
+		}
+	}
+
+	// diff
+	if m.Page == PageDiff {
+		switch msg := msg.(type) {
+		case PullRequestFilesLoaded:
+			if !sameNotification(msg.Notification, m.Notification) {
+				return m, nil
+			}
+			// rendered once, as highlighting is too slow for every frame
+			d := renderDiff(msg.Files, codeTheme(config))
+			m.DiffText = d.Text
+			m.DiffLines = d.Lines
+			m.DiffFileOffsets = d.Files
+			m.DiffHunkOffsets = d.Hunks
+			m.LoadingDiff = false
+			return m, nil
+		case *terminput.KeyboardInput:
+			switch msg.Key() {
+			case terminput.KeyEscape, terminput.KeyLeft:
+				m.Page = PageNotification
+				return m, nil
+			case terminput.KeyUp:
+				m.DiffScrollY = max(0, m.DiffScrollY-m.Height/4)
+				return m, nil
+			case terminput.KeyDown:
+				m.DiffScrollY = clampDiffScroll(m, m.DiffScrollY+m.Height/4)
+				return m, nil
+			case terminput.KeyRune:
+				switch msg.Rune() {
+				case 'n':
+					m.DiffScrollY = clampDiffScroll(m, nextOffset(m.DiffFileOffsets, m.DiffScrollY))
+				case 'N':
+					m.DiffScrollY = prevOffset(m.DiffFileOffsets, m.DiffScrollY)
+				case ']':
+					m.DiffScrollY = clampDiffScroll(m, nextOffset(m.DiffHunkOffsets, m.DiffScrollY))
+				case '[':
+					m.DiffScrollY = prevOffset(m.DiffHunkOffsets, m.DiffScrollY)
+				}
+				return m, nil
+			}
+		}
+	}
+
 	// labels
 	if m.Page == PageLabels {
 		switch msg := msg.(type) {
 		case LabelsLoaded:
+			// refreshed after rendering from the cache
+			if !m.Loading {
+				selected := m.LabelOptions.Value()
+				m.RepoLabels = filterPriorityLabels(msg.Labels, config.Priorities)
+				m.LabelOptions.Options = labelNames(m.RepoLabels)
+				m.LabelOptions.Selected = namesSelected(m.LabelOptions.Options, selected)
+				return m, nil
+			}
+
 			m.RepoLabels = filterPriorityLabels(msg.Labels, config.Priorities)
 			m.Loading = false
-			return m, LoadNotificationLabels(m.Notification, m.Issue)
+			if m.BulkEditing {
+				m.LabelOptions = options.Model{
+					Options: labelNames(m.RepoLabels),
+				}
+				m.LoadingLabels = false
+				return m, nil
+			}
+
+			var refresh tea.Cmd
+			if msg.Cached {
+				refresh = Refresh(LoadRepoLabels(m.Notification))
+			}
+
+			return m, tea.Batch(Refresh(LoadNotificationLabels(m.Notification, m.Issue)), refresh)
 		case NotificationLabelsLoaded:
+			if !sameNotification(msg.Notification, m.Notification) {
+				return m, nil
+			}
 			m.LabelOptions = options.Model{
 				Options:  labelNames(m.RepoLabels),
 				Selected: labelsSelected(m.RepoLabels, msg.Labels),
@@ -125,16 +672,38 @@ func Update(ctx context.Context, msg tea.Msg, model tea.Model) (tea.Model, tea.C
 		case NotificationLabelsUpdated:
 			m.Page = PageNotification
 			m.LoadingLabels = true
-			return m, LoadNotificationLabels(m.Notification, m.Issue)
+			return m, Refresh(LoadNotificationLabels(m.Notification, m.Issue))
 		case *terminput.KeyboardInput:
 			switch msg.Key() {
 			case terminput.KeyEnter:
-				m.Page = PageNotification
+				// the options are empty until loaded, which would remove every label
+				if m.Loading {
+					return m, nil
+				}
 				labels := m.LabelOptions.Value()
-				return m, UpdateNotificationLabels(m.Notification, m.Issue, labels)
+				if m.BulkEditing {
+					return bulkAddLabels(m, labels)
+				}
+				m.Page = PageNotification
+				if labelsLoaded(m) {
+					m = pushUndo(m, Undo{
+						Op:           OpUpdateLabels,
+						Notification: m.Notification,
+						Issue:        m.Issue,
+						Labels:       append([]string{}, labelNames(m.Labels)...),
+					})
+				}
+				return m, UpdateNotificationLabels(m.Notification, shownIssue(m), labels)
 			case terminput.KeyEscape:
+				// labels loaded after aborting are dropped, as they are only handled on this page
+				m.Loading = false
+				m.LoadingLabels = false
 				m.LabelOptions = options.Model{}
 				m.Page = PageNotification
+				if m.BulkEditing {
+					m.Page = PageNotifications
+					m.BulkEditing = false
+				}
 				return m, nil
 			default:
 				m.LabelOptions = options.Update(msg, m.LabelOptions)
@@ -143,22 +712,86 @@ func Update(ctx context.Context, msg tea.Msg, model tea.Model) (tea.Model, tea.C
 		}
 	}
 
+	// assignees
+	if m.Page == PageAssignees {
+		switch msg := msg.(type) {
+		case AssigneesLoaded:
+			m.RepoAssignees = msg.Users
+
+			// refreshed after rendering from the cache
+			if !m.Loading {
+				selected := m.AssigneeOptions.Value()
+				m.AssigneeOptions.Options = assigneeOptions(m.RepoAssignees, m.Issue)
+				m.AssigneeOptions.Selected = namesSelected(m.AssigneeOptions.Options, selected)
+				return m, nil
+			}
+
+			m.Loading = false
+			logins := assigneeOptions(m.RepoAssignees, m.Issue)
+			m.AssigneeOptions = options.Model{
+				Options:  logins,
+				Selected: namesSelected(logins, assigneeLogins(m.Issue)),
+			}
+
+			if msg.Cached {
+				return m, Refresh(LoadRepoAssignees(m.Notification))
+			}
+			return m, nil
+		case *terminput.KeyboardInput:
+			switch msg.Key() {
+			case terminput.KeyEnter:
+				// the options are empty until loaded, which would unassign everyone
+				if m.Loading {
+					return m, nil
+				}
+				logins := m.AssigneeOptions.Value()
+				m.AssigneeOptions = options.Model{}
+				m.Page = PageNotification
+				return m, UpdateNotificationAssignees(m.Notification, m.Issue, logins)
+			case terminput.KeyEscape:
+				// assignees loaded after aborting are dropped, as they are only handled on this page
+				m.Loading = false
+				m.AssigneeOptions = options.Model{}
+				m.Page = PageNotification
+				return m, nil
+			default:
+				m.AssigneeOptions = options.Update(msg, m.AssigneeOptions)
+				return m, nil
+			}
+		}
+	}
+
 	// priorities
 	if m.Page == PagePriorities {
 		switch msg := msg.(type) {
 		case NotificationPriorityUpdated:
 			m.Page = PageNotification
 			m.LoadingLabels = true
-			return m, LoadNotificationLabels(m.Notification, m.Issue)
+			return m, Refresh(LoadNotificationLabels(m.Notification, m.Issue))
 		case *terminput.KeyboardInput:
 			switch msg.Key() {
 			case terminput.KeyEnter:
-				m.Page = PageNotification
 				name := m.PriorityOptions.Value()
-				return m, UpdateNotificationPriority(m.Notification, m.Issue, name)
+				if m.BulkEditing {
+					return bulkUpdatePriority(m, name)
+				}
+				m.Page = PageNotification
+				if labelsLoaded(m) {
+					m = pushUndo(m, Undo{
+						Op:           OpUpdatePriority,
+						Notification: m.Notification,
+						Issue:        m.Issue,
+						Labels:       append([]string{}, labelNames(m.Labels)...),
+					})
+				}
+				return m, UpdateNotificationPriority(m.Notification, shownIssue(m), name)
 			case terminput.KeyEscape:
 				m.LabelOptions = options.Model{}
 				m.Page = PageNotification
+				if m.BulkEditing {
+					m.Page = PageNotifications
+					m.BulkEditing = false
+				}
 				return m, nil
 			default:
 				m.PriorityOptions = option.Update(msg, m.PriorityOptions)
@@ -172,21 +805,125 @@ func Update(ctx context.Context, msg tea.Msg, model tea.Model) (tea.Model, tea.C
 		switch msg := msg.(type) {
 		case CommentAdded:
 			m.LoadingComments = true
-			return m, LoadNotificationComments(m.Issue)
+			return m, Refresh(LoadNotificationComments(m.Notification, m.Issue))
+		case NotificationLabelsUpdated, NotificationPriorityUpdated:
+			m.LoadingLabels = true
+			return m, tea.Batch(
+				Refresh(LoadNotificationLabels(m.Notification, m.Issue)),
+				Refresh(LoadNotificationEvents(m.Notification, m.Issue)),
+			)
+		case IssueStateUpdated:
+			if !sameNotification(msg.Notification, m.Notification) {
+				return m, nil
+			}
+			m.Issue = msg.Issue
+			m.StateReason = msg.StateReason
+			m.PendingState = ""
+			m.LoadingEvents = true
+			cmds := []tea.Cmd{Refresh(LoadNotificationEvents(m.Notification, m.Issue))}
+			if msg.Commented {
+				m.LoadingComments = true
+				cmds = append(cmds, Refresh(LoadNotificationComments(m.Notification, m.Issue)))
+			}
+			if m.PullRequest != nil {
+				cmds = append(cmds, LoadNotificationPullRequest(m.Notification))
+			}
+			return m, tea.Batch(cmds...)
+		case AssigneesUpdated:
+			if !sameNotification(msg.Notification, m.Notification) {
+				return m, nil
+			}
+			m.Issue = msg.Issue
+			m.LoadingEvents = true
+			return m, Refresh(LoadNotificationEvents(m.Notification, m.Issue))
+		case IssueLockUpdated:
+			if !sameNotification(msg.Notification, m.Notification) {
+				return m, nil
+			}
+			m.Issue = msg.Issue
+			m.PendingState = ""
+			m.LoadingEvents = true
+			return m, Refresh(LoadNotificationEvents(m.Notification, m.Issue))
 		case NotificationIssueLoaded:
+			if !sameNotification(msg.Notification, m.Notification) {
+				return m, nil
+			}
 			m.Issue = msg.Issue
+			m.StateReason = msg.StateReason
+
+			// refreshed after rendering from the cache
+			if !m.LoadingIssue {
+				return m, nil
+			}
+
 			m.LoadingIssue = false
+
+			var refresh tea.Cmd
+			if msg.Cached {
+				refresh = Refresh(LoadNotificationIssue(m.Notification))
+			}
+
 			return m, tea.Batch(
 				LoadNotificationLabels(m.Notification, msg.Issue),
-				LoadNotificationComments(msg.Issue),
+				LoadNotificationComments(m.Notification, msg.Issue),
+				LoadNotificationEvents(m.Notification, msg.Issue),
+				refresh,
 			)
+		case NotificationPullRequestLoaded:
+			if !sameNotification(msg.Notification, m.Notification) {
+				return m, nil
+			}
+			m.PullRequest = msg.PullRequest
+			m.LoadingPullRequest = false
+			return m, tea.Batch(
+				LoadPullRequestReviews(m.Notification, msg.PullRequest),
+				LoadPullRequestChecks(m.Notification, msg.PullRequest),
+			)
+		case ReviewSubmitted:
+			return m, LoadPullRequestReviews(m.Notification, m.PullRequest)
+		case NotificationReviewsLoaded:
+			if !sameNotification(msg.Notification, m.Notification) {
+				return m, nil
+			}
+			m.Reviews = msg.Reviews
+			return m, nil
+		case NotificationChecksLoaded:
+			if !sameNotification(msg.Notification, m.Notification) {
+				return m, nil
+			}
+			m.CheckRuns = msg.CheckRuns
+			m.Status = msg.Status
+			return m, nil
 		case NotificationLabelsLoaded:
+			if !sameNotification(msg.Notification, m.Notification) {
+				return m, nil
+			}
 			m.LoadingLabels = false
 			m.Labels = msg.Labels
+			m.LabelsLoaded = true
+			if msg.Cached {
+				return m, Refresh(LoadNotificationLabels(m.Notification, m.Issue))
+			}
 			return m, nil
 		case NotificationCommentsLoaded:
+			if !sameNotification(msg.Notification, m.Notification) {
+				return m, nil
+			}
 			m.LoadingComments = false
 			m.Comments = msg.Comments
+			if msg.Cached {
+				return m, Refresh(LoadNotificationComments(m.Notification, m.Issue))
+			}
+			return m, nil
+		case NotificationEventsLoaded:
+			if !sameNotification(msg.Notification, m.Notification) {
+				return m, nil
+			}
+			m.LoadingEvents = false
+			m.Events = msg.Events
+			if msg.Cached {
+				return m, Refresh(LoadNotificationEvents(m.Notification, m.Issue))
+			}
 			return m, nil
 		case *terminput.KeyboardInput:
 			switch msg.Key() {
@@ -206,8 +943,8 @@ func Update(ctx context.Context, msg tea.Msg, model tea.Model) (tea.Model, tea.C
 				return m, nil
 			case terminput.KeyBackspace:
 				m.Page = PageNotifications
-				m.MarkingAsRead = true
-				return m, MarkAsRead(m.Notification)
+				m.NotificationScrollY = 0
+				return markAsRead(m, []*github.Notification{m.Notification})
 			case terminput.KeyRune:
 				switch r := msg.Rune(); r {
 				case 'R':
@@ -215,19 +952,36 @@ func Update(ctx context.Context, msg tea.Msg, model tea.Model) (tea.Model, tea.C
 					m.Comments = nil
 					return loadNotification(m, m.Notification)
 				case 'r':
-					m.MarkingAsRead = true
-					return m, MarkAsRead(m.Notification)
+					m.Page = PageNotifications
+					m.NotificationScrollY = 0
+					return markAsRead(m, []*github.Notification{m.Notification})
 				case 'u':
-					m.Unsubscribing = true
-					return m, Unsubscribe(m.Notification)
+					m.Page = PageNotifications
+					m.NotificationScrollY = 0
+					return unsubscribe(m, []*github.Notification{m.Notification})
+				case 'z':
+					return undo(m)
 				case 'o':
 					return m, OpenInBrowser(m.Notification)
 				case 'l':
+					if !labelsLoaded(m) {
+						return m, nil
+					}
 					m.Page = PageLabels
 					m.Loading = true
 					m.LoadingLabels = true
 					return m, LoadRepoLabels(m.Notification)
+				case 'a':
+					if m.Issue == nil {
+						return m, nil
+					}
+					m.Page = PageAssignees
+					m.Loading = true
+					return m, LoadRepoAssignees(m.Notification)
 				case 'p':
+					if !labelsLoaded(m) {
+						return m, nil
+					}
 					var o option.Model
 					m.Page = PagePriorities
 					for _, p := range config.Priorities {
@@ -238,6 +992,64 @@ func Update(ctx context.Context, msg tea.Msg, model tea.Model) (tea.Model, tea.C
 				case 'c':
 					m.Page = PageComment
 					return m, nil
+				case 'C':
+					if config.CollapseComments {
+						m.ShowOlderComments = !m.ShowOlderComments
+					}
+					return m, nil
+				case 't':
+					m.HideEvents = !m.HideEvents
+					return m, nil
+				case 'd':
+					if m.PullRequest == nil {
+						return m, nil
+					}
+					m.Page = PageDiff
+					m.DiffScrollY = 0
+					m.LoadingDiff = true
+					return m, LoadPullRequestFiles(m.Notification, m.PullRequest)
+				case 'v':
+					if m.PullRequest == nil {
+						return m, nil
+					}
+					m.Page = PageReview
+					m.ReviewEvent = ""
+					m.ReviewOptions = option.Model{
+						Options: []string{"Approve", "Request changes", "Comment"},
+					}
+					return m, nil
+				case 'x':
+					if m.Issue == nil || m.PendingState != "" {
+						return m, nil
+					}
+					if m.Issue.GetState() == "closed" {
+						m.PendingState = "Reopening"
+						return m, ReopenIssue(m.Notification, m.Issue)
+					}
+					m.Page = PageClose
+					m.CloseReason = ""
+					m.CloseOptions = option.Model{
+						Options: []string{"Completed", "Not planned", "Duplicate"},
+					}
+					return m, nil
+				case 'L':
+					if m.Issue == nil || m.PendingState != "" {
+						return m, nil
+					}
+					if m.Issue.GetLocked() {
+						m.PendingState = "Unlocking"
+						return m, UnlockIssue(m.Notification, m.Issue)
+					}
+					m.Page = PageLock
+					m.LockOptions = option.Model{
+						Options: []string{"No reason", "Off-topic", "Too heated", "Resolved", "Spam"},
+					}
+					return m, nil
+				case 'e':
+					m.ErrorsReturn = m.Page
+					m.Page = PageErrors
+					m.Toast = false
+					return m, nil
 				}
 			}
 		}
@@ -271,14 +1083,58 @@ func Update(ctx context.Context, msg tea.Msg, model tea.Model) (tea.Model, tea.C
 
 		// listing
 		switch msg := msg.(type) {
-		case NotificationsLoaded:
-			m.Notifications = msg.Notifications
-			m.Loading = false
-			return m, nil
 		case *terminput.KeyboardInput:
-			if len(notifications) == 0 {
+			// keys which do not require a selection
+			switch msg.Key() {
+			case terminput.KeyTAB:
+				return switchView(m, (m.ActiveView+1)%len(m.Views)), nil
+			case terminput.KeyBacktab:
+				return switchView(m, (m.ActiveView+len(m.Views)-1)%len(m.Views)), nil
+			case terminput.KeyRune:
+				switch r := msg.Rune(); r {
+				case 'R':
+					m.Loading = true
+					return m, LoadNotifications
+				case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+					return switchView(m, int(r-'1')), nil
+				case 'g':
+					return toggleGrouped(m), nil
+				case 'H':
+					return toggleHidden(m)
+				case 's':
+					m.Sort = m.Sort.Next()
+					m = sortModel(m, config.Priorities)
+					m.Notice = fmt.Sprintf("Sorted by %s", m.Sort)
+					m.NoticeID++
+					cmds := []tea.Cmd{SaveSortOrder(m.Sort), ExpireNotice(m.NoticeID)}
+					if missing := missingIssues(m); m.Sort.NeedsIssues() && len(missing) > 0 {
+						cmds = append(cmds, LoadNotificationsIssues(missing))
+					}
+					return m, tea.Batch(cmds...)
+				case 'z':
+					return undo(m)
+				case '/':
+					m.Searching = true
+					return m, nil
+				case 'e':
+					m.ErrorsReturn = m.Page
+					m.Page = PageErrors
+					m.Toast = false
+					return m, nil
+				}
+			}
+
+			if len(shownNotifications(m)) == 0 {
 				return m, tea.Quit
 			}
+
+			// nothing matches the view or search
+			if len(items) == 0 {
+				break
+			}
+
+			item := items[m.Selected]
+
 			switch msg.Key() {
 			case terminput.KeyUp:
 				if m.Selected > 0 {
@@ -286,53 +1142,83 @@ func Update(ctx context.Context, msg tea.Msg, model tea.Model) (tea.Model, tea.C
 				} else if m.SearchInput.Value != "" {
 					m.Searching = true
 				}
-				m.NotificationsScrollY = scrollNotifications(m, notifications, 1)
-				return m, nil
+				m.NotificationsScrollY = scrollNotifications(m, items, 1)
+				return prefetch(m, items)
 			case terminput.KeyDown:
-				if m.Selected < len(notifications)-1 {
+				if m.Selected < len(items)-1 {
 					m.Selected++
 				}
-				m.NotificationsScrollY = scrollNotifications(m, notifications, -1)
-				return m, nil
+				m.NotificationsScrollY = scrollNotifications(m, items, -1)
+				return prefetch(m, items)
 			case terminput.KeyEnter, terminput.KeyRight:
-				n := notifications[m.Selected]
+				if item.Header {
+					m = toggleCollapsed(m, item.Repo)
+					return m, nil
+				}
+				n := item.Notification()
 				m.Page = PageNotification
 				m.NotificationScrollY = 0
 				m.Issue = nil
 				m.Labels = nil
 				m.Comments = nil
 				return loadNotification(m, n)
+			case terminput.KeyLeft:
+				if m.Grouped && !m.Collapsed[item.Repo] {
+					m = toggleCollapsed(m, item.Repo)
+					m.Selected = getItemIndex(listItems(m, notifications), listItem{Repo: item.Repo, Header: true})
+					m.NotificationsScrollY = scrollNotifications(m, listItems(m, notifications), 1)
+					return m, nil
+				}
 			case terminput.KeyBackspace:
-				n := notifications[m.Selected]
-				m.MarkingAsRead = true
-				return m, MarkAsRead(n)
+				return markAsRead(m, targetNotifications(m, item))
+			case terminput.KeyEscape:
+				if len(m.Checked) > 0 {
+					m.Checked = nil
+					return m, nil
+				}
 			case terminput.KeyRune:
-				switch r := msg.Rune(); r {
-				case 'R':
-					m.Loading = true
-					return m, LoadNotifications
+				switch msg.Rune() {
+				case ' ':
+					m = toggleChecked(m, item.Notifications)
+					return m, nil
+				case 'a':
+					m = toggleChecked(m, notifications)
+					return m, nil
+				case 'A':
+					owner, repo := ownerRepo(item.Notifications[0])
+					m = toggleChecked(m, getNotificationsByRepo(notifications, owner, repo))
+					return m, nil
 				case 'r':
-					n := notifications[m.Selected]
-					m.MarkingAsRead = true
-					return m, MarkAsRead(n)
+					return markAsRead(m, targetNotifications(m, item))
 				case 'u':
-					n := notifications[m.Selected]
-					m.Unsubscribing = true
-					return m, Unsubscribe(n)
+					return unsubscribe(m, targetNotifications(m, item))
 				case 'U':
-					n := m.Notifications[m.Selected]
-					owner, repo := ownerRepo(n)
-					var cmds []tea.Cmd
-					cmds = append(cmds, Unwatch(owner, repo))
-					for _, n := range getNotificationsByRepo(m.Notifications, owner, repo) {
-						cmds = append(cmds, MarkAsRead(n))
+					return unwatch(m, targetNotifications(m, item))
+				case 'l':
+					if len(m.Checked) == 0 {
+						return m, nil
 					}
-					return m, tea.Batch(cmds...)
+					m.Page = PageLabels
+					m.BulkEditing = true
+					m.Loading = true
+					m.LoadingLabels = true
+					return m, LoadReposLabels(checkedNotifications(m))
+				case 'p':
+					if len(m.Checked) == 0 {
+						return m, nil
+					}
+					var o option.Model
+					m.Page = PagePriorities
+					m.BulkEditing = true
+					for _, p := range config.Priorities {
+						o.Options = append(o.Options, p.Name)
+					}
+					m.PriorityOptions = o
+					return m, nil
 				case 'o':
-					n := notifications[m.Selected]
-					return m, OpenInBrowser(n)
-				case '/':
-					m.Searching = true
+					if n := item.Notification(); n != nil {
+						return m, OpenInBrowser(n)
+					}
 					return m, nil
 				}
 			}
@@ -341,26 +1227,9 @@ func Update(ctx context.Context, msg tea.Msg, model tea.Model) (tea.Model, tea.C
 
 	// shared messages
 	if m.Page == PageNotification || m.Page == PageNotifications {
-		switch msg := msg.(type) {
-		case Unsubscribed:
-			m.Page = PageNotifications
-			m.Notifications = removeNotification(m.Notifications, msg.GetID())
-			m.Selected = min(m.Selected, len(notifications)-1)
-			m.Unsubscribing = false
-			m.NotificationScrollY = 0
-			return m, nil
-		case MarkedAsRead:
-			m.Page = PageNotifications
-			m.Notifications = removeNotification(m.Notifications, msg.GetID())
-			m.Selected = min(m.Selected, len(notifications)-1)
-			m.MarkingAsRead = false
-			m.NotificationScrollY = 0
-			return m, nil
-		case Unwatched:
-			m.Page = PageNotifications
-			m.Unwatching = false
-			m.Selected = min(m.Selected, len(notifications)-1)
-			m.NotificationScrollY = 0
+		switch msg.(type) {
+		case Unsubscribed, MarkedAsRead, Unwatched:
+			// applied optimistically
 			return m, nil
 		}
 	}
@@ -385,12 +1254,275 @@ func Update(ctx context.Context, msg tea.Msg, model tea.Model) (tea.Model, tea.C
 // loadNotification loads the notification.
 func loadNotification(m Model, n *github.Notification) (Model, tea.Cmd) {
 	m.Notification = n
+	m.ShowOlderComments = false
+	m.PendingState = ""
 	m.LoadingIssue = true
 	m.LoadingLabels = true
 	m.LoadingComments = true
+	m.LoadingEvents = true
+	m.LabelsLoaded = false
+	m.PullRequest = nil
+	m.Reviews = nil
+	m.CheckRuns = nil
+	m.Status = nil
+	m.LoadingPullRequest = isPullRequest(n)
+
+	// render the prefetched details immediately, refreshing them in the background
+	if d, ok := m.Prefetched[notificationKey(n)]; ok {
+		m = forgetPrefetched(m, []*github.Notification{n})
+		m.Issue = d.Issue
+		m.StateReason = d.StateReason
+		m.Labels = d.Labels
+		m.LabelsLoaded = true
+		m.Comments = d.Comments
+		m.Events = d.Events
+		m.LoadingIssue = false
+		m.LoadingLabels = false
+		m.LoadingComments = false
+		m.LoadingEvents = false
+
+		cmds := []tea.Cmd{
+			Refresh(LoadNotificationIssue(n)),
+			Refresh(LoadNotificationLabels(n, d.Issue)),
+			Refresh(LoadNotificationComments(n, d.Issue)),
+			Refresh(LoadNotificationEvents(n, d.Issue)),
+		}
+
+		if isPullRequest(n) {
+			cmds = append(cmds, LoadNotificationPullRequest(n))
+		}
+
+		return m, tea.Batch(cmds...)
+	}
+
+	m.Events = nil
 	return m, LoadNotification(n)
 }
 
+// replayOutbox returns a command sending the queued changes when online.
+func replayOutbox(m Model) (Model, tea.Cmd) {
+	if m.Offline || m.Queued == 0 || m.Replaying {
+		return m, nil
+	}
+	m.Replaying = true
+	return m, ReplayOutbox
+}
+
+// applyQueued applies a queued label change to the labels displayed, as they cannot be reloaded while offline.
+func applyQueued(m Model, c Change, priorities []Priority) Model {
+	if m.Notification == nil || notificationKey(m.Notification) != notificationKey(c.Notification) {
+		return m
+	}
+
+	// known labels, for their colors
+	known := append(append([]*github.Label{}, m.RepoLabels...), m.Labels...)
+	label := func(name string) *github.Label {
+		for _, l := range known {
+			if equal(l.GetName(), name) {
+				return l
+			}
+		}
+		return &github.Label{Name: &name}
+	}
+
+	var labels []*github.Label
+	switch c.Op {
+	case OpUpdateLabels:
+		if c.Add {
+			labels = append(labels, m.Labels...)
+		}
+		for _, name := range c.Labels {
+			if !c.Add || !includes(labelNames(labels), name) {
+				labels = append(labels, label(name))
+			}
+		}
+	case OpUpdatePriority:
+		labels = filterPriorityLabels(m.Labels, priorities)
+		for _, p := range priorities {
+			if p.Name == c.Priority {
+				name := p.Label
+				color := strings.Replace(p.Color, "#", "", 1)
+				labels = append(labels, &github.Label{
+					Name:  &name,
+					Color: &color,
+				})
+			}
+		}
+	default:
+		return m
+	}
+
+	m.Labels = labels
+	m.LoadingLabels = false
+	return m
+}
+
+// shownIssue returns the issue with the labels displayed, which are current
+// unlike those of the issue when loaded, or without labels until they load.
+func shownIssue(m Model) *github.Issue {
+	if m.Issue == nil {
+		return nil
+	}
+
+	issue := *m.Issue
+	issue.Labels = nil
+
+	if m.LabelsLoaded && !m.LoadingLabels {
+		issue.Labels = []github.Label{}
+		for _, l := range m.Labels {
+			issue.Labels = append(issue.Labels, *l)
+		}
+	}
+
+	return &issue
+}
+
+// labelsLoaded returns true if the issue and its labels are loaded, which
+// label changes and their undo are based on.
+func labelsLoaded(m Model) bool {
+	return m.Issue != nil && m.LabelsLoaded && !m.LoadingLabels
+}
+
+// removeRead removes the notifications which were marked as read.
+func removeRead(m Model, keys []string) Model {
+	if len(keys) == 0 {
+		return m
+	}
+	notifications := append([]*github.Notification{}, m.Notifications...)
+	for _, key := range keys {
+		notifications = removeNotification(notifications, key)
+	}
+	m.Notifications = notifications
+	m.Selected = clampSelected(m)
+	return m
+}
+
+// targetNotifications returns the checked notifications, or the notifications of the selected item.
+func targetNotifications(m Model, item listItem) []*github.Notification {
+	if len(m.Checked) > 0 {
+		return checkedNotifications(m)
+	}
+	return item.Notifications
+}
+
+// bulkAddLabels adds labels to the checked notifications' issues.
+func bulkAddLabels(m Model, labels []string) (Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	for _, n := range checkedNotifications(m) {
+		if issue := subjectIssue(n); issue != nil && len(labels) > 0 {
+			cmds = append(cmds, AddNotificationLabels(n, issue, labels))
+		}
+	}
+	m.Page = PageNotifications
+	m.BulkEditing = false
+	m.Checked = nil
+	return startBatch(m, "Labeling", cmds)
+}
+
+// bulkUpdatePriority updates the priority of the checked notifications' issues.
+func bulkUpdatePriority(m Model, name string) (Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	for _, n := range checkedNotifications(m) {
+		if issue := subjectIssue(n); issue != nil {
+			cmds = append(cmds, UpdateNotificationPriority(n, issue, name))
+		}
+	}
+	m.Page = PageNotifications
+	m.BulkEditing = false
+	m.Checked = nil
+	return startBatch(m, "Prioritizing", cmds)
+}
+
+// failed records a failed operation, clearing its in-flight state
+// and restoring notifications which were optimistically removed.
+func failed(m Model, f Failed) Model {
+	if !staleLoad(m, f) {
+		m = clearPending(m, f.Op)
+	}
+	m.Errors = append(m.Errors, f)
+
+	switch f.Op {
+	case OpMarkAsRead, OpUnsubscribe:
+		if f.Notification != nil {
+			m = undoFailed(m, f.Notification)
+		}
+	}
+
+	return m
+}
+
+// staleLoad returns true if the failure is of loading the details of a
+// notification other than the one displayed, which is still loading.
+func staleLoad(m Model, f Failed) bool {
+	switch f.Op {
+	case OpLoadIssue, OpLoadLabels, OpLoadComments, OpLoadEvents, OpLoadPullRequest, OpLoadDiff:
+		return f.Notification != nil && !sameNotification(f.Notification, m.Notification)
+	default:
+		return false
+	}
+}
+
+// clearPending clears the in-flight state of a failed operation.
+func clearPending(m Model, op Op) Model {
+	switch op {
+	case OpLoadNotifications:
+		m.Loading = false
+		m.LoadingPage = 0
+		m.PendingNotifications = nil
+	case OpLoadIssue:
+		m.LoadingIssue = false
+		m.LoadingLabels = false
+		m.LoadingComments = false
+		m.LoadingEvents = false
+	case OpLoadLabels:
+		m.LoadingLabels = false
+	case OpLoadComments:
+		m.LoadingComments = false
+	case OpLoadEvents:
+		m.LoadingEvents = false
+	case OpUpdateState, OpLock:
+		m.PendingState = ""
+	case OpLoadAssignees:
+		m.Loading = false
+		if m.Page == PageAssignees {
+			m.Page = PageNotification
+		}
+	case OpLoadPullRequest:
+		m.LoadingPullRequest = false
+	case OpLoadDiff:
+		m.LoadingDiff = false
+	case OpLoadRepoLabels:
+		m.Loading = false
+		m.LoadingLabels = false
+		if m.Page == PageLabels {
+			m.Page = PageNotification
+		}
+	}
+	return m
+}
+
+// mergePolled merges polled notifications into the model, retaining the
+// selected notification and its position within the viewport.
+func mergePolled(m Model, items []listItem, updates []*github.Notification, priorities []Priority) Model {
+	m.Notifications, m.Highlighted = mergeNotifications(m.Notifications, updates)
+	m.Notifications = sortNotifications(m.Notifications, m.Sort, m.Issues, priorities)
+
+	if m.Selected >= len(items) {
+		return m
+	}
+
+	selected := items[m.Selected]
+	before := itemsHeight(items[:m.Selected])
+
+	items = listItems(m, visibleNotifications(m))
+	if i := getItemIndex(items, selected); i != -1 {
+		m.NotificationsScrollY = max(0, m.NotificationsScrollY+itemsHeight(items[:i])-before)
+		m.Selected = i
+	}
+
+	return m
+}
+
 // labelNames returns label names, filtering priorities.
 func labelNames(labels []*github.Label) (names []string) {
 	for _, l := range labels {
@@ -399,6 +1531,38 @@ func labelNames(labels []*github.Label) (names []string) {
 	return
 }
 
+// assigneeLogins returns the logins of the issue's assignees.
+func assigneeLogins(issue *github.Issue) (logins []string) {
+	for _, u := range issue.Assignees {
+		logins = append(logins, u.GetLogin())
+	}
+	return
+}
+
+// assigneeOptions returns the logins of the assignable users, followed by
+// any current assignees who are no longer assignable, so they may be removed.
+func assigneeOptions(users []*github.User, issue *github.Issue) (logins []string) {
+	for _, u := range users {
+		logins = append(logins, u.GetLogin())
+	}
+	for _, login := range assigneeLogins(issue) {
+		if !includes(logins, login) {
+			logins = append(logins, login)
+		}
+	}
+	return
+}
+
+// namesSelected returns the indexes of the selected names.
+func namesSelected(names []string, selected []string) (indexes []int) {
+	for i, name := range names {
+		if includes(selected, name) {
+			indexes = append(indexes, i)
+		}
+	}
+	return
+}
+
 // labelsSelected returns the indexes of selected labels.
 func labelsSelected(labels []*github.Label, selected []*github.Label) (indexes []int) {
 	for i, l := range labels {
@@ -412,13 +1576,80 @@ func labelsSelected(labels []*github.Label, selected []*github.Label) (indexes [
 	return
 }
 
+// switchView switches to the saved view, remembering the position of the current view.
+func switchView(m Model, view int) Model {
+	if view >= len(m.Views) || view == m.ActiveView {
+		return m
+	}
+
+	positions := make(map[int]ViewPosition)
+	for k, v := range m.ViewPositions {
+		positions[k] = v
+	}
+
+	positions[m.ActiveView] = ViewPosition{
+		Selected: m.Selected,
+		ScrollY:  m.NotificationsScrollY,
+	}
+
+	p := positions[view]
+	m.ViewPositions = positions
+	m.ActiveView = view
+	m.Selected = p.Selected
+	m.NotificationsScrollY = p.ScrollY
+	m.Selected = clampSelected(m)
+	return m
+}
+
+// clampSelected returns the selection bounded by the list items.
+func clampSelected(m Model) int {
+	items := listItems(m, visibleNotifications(m))
+	return max(0, min(m.Selected, len(items)-1))
+}
+
+// toggleHidden toggles the display of notifications hidden by the filter, retaining the selection.
+func toggleHidden(m Model) (Model, tea.Cmd) {
+	selected, ok := selectedItem(m)
+	m.ShowHidden = !m.ShowHidden
+	m.Selected = 0
+	m.NotificationsScrollY = 0
+	if ok {
+		m = selectItem(m, selected)
+	}
+
+	if m.ShowHidden {
+		m.Notice = fmt.Sprintf("Showing %d hidden notifications", countHidden(m))
+	} else {
+		m.Notice = fmt.Sprintf("Hiding %d notifications", countHidden(m))
+	}
+
+	m.NoticeID++
+	return m, ExpireNotice(m.NoticeID)
+}
+
+// toggleGrouped toggles grouping by repository, retaining the selection.
+func toggleGrouped(m Model) Model {
+	selected, ok := selectedItem(m)
+	m.Grouped = !m.Grouped
+	m.Selected = 0
+	m.NotificationsScrollY = 0
+	if ok {
+		m = selectItem(m, selected)
+	}
+	return m
+}
+
 // scrollNotifications returns the scroll position based on the current selection.
-func scrollNotifications(m Model, notifications []*github.Notification, direction int) int {
-	selectedHeight := m.Selected * listItemHeight
-	listHeight := len(notifications)*listItemHeight + 2
+func scrollNotifications(m Model, items []listItem, direction int) int {
+	selectedHeight := itemsHeight(items[:min(m.Selected, len(items))])
+	listHeight := itemsHeight(items) + 2
 	padding := m.Height / 2
 
-	if m.Searching {
+	if len(m.Views) > 1 {
+		listHeight += 2
+	}
+
+	if m.Searching || m.SearchInput.Value != "" {
 		listHeight += 2
 	}
 
@@ -451,9 +1682,9 @@ func getNotificationsByRepo(notifications []*github.Notification, owner, repo st
 	return
 }
 
-// removeNotification by id if present.
-func removeNotification(notifications []*github.Notification, id string) []*github.Notification {
-	i := getNotificationIndex(notifications, id)
+// removeNotification by key if present.
+func removeNotification(notifications []*github.Notification, key string) []*github.Notification {
+	i := getNotificationIndex(notifications, key)
 	if i == -1 {
 		return notifications
 	}
@@ -461,10 +1692,10 @@ func removeNotification(notifications []*github.Notification, id string) []*gith
 }
 
 // getNotificationIndex returns the index of a notification, or -1.
-func getNotificationIndex(notifications []*github.Notification, id string) int {
+func getNotificationIndex(notifications []*github.Notification, key string) int {
 	index := -1
 	for i, n := range notifications {
-		if n.GetID() == id {
+		if notificationKey(n) == key {
 			index = i
 		}
 	}
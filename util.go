@@ -1,15 +1,22 @@
 package triage
 
 import (
-	"strings"
+	"path"
+	"strconv"
 
 	"github.com/google/go-github/v28/github"
 )
 
-// filterNotifications using the given text.
+// filterNotifications using the given search query, falling
+// back to free text matching when the query is invalid.
 func filterNotifications(notifications []*github.Notification, s string) (filtered []*github.Notification) {
+	query, err := ParseQuery(s)
+	if err != nil {
+		query = Text{s}
+	}
+
 	for _, n := range notifications {
-		if !strings.Contains(n.Repository.GetFullName(), s) {
+		if !query.Match(n) {
 			continue
 		}
 		filtered = append(filtered, n)
@@ -17,6 +24,200 @@ func filterNotifications(notifications []*github.Notification, s string) (filter
 	return
 }
 
+// shownNotifications returns the notifications, without those
+// hidden by the configured filter unless they are being shown.
+func shownNotifications(m Model) (shown []*github.Notification) {
+	if m.ShowHidden {
+		return m.Notifications
+	}
+
+	for _, n := range m.Notifications {
+		if !isHidden(m, n) {
+			shown = append(shown, n)
+		}
+	}
+	return
+}
+
+// countHidden returns the number of notifications hidden by the filter.
+func countHidden(m Model) (n int) {
+	for _, v := range m.Notifications {
+		if isHidden(m, v) {
+			n++
+		}
+	}
+	return
+}
+
+// isHidden returns true if the notification is hidden by the filter.
+func isHidden(m Model, n *github.Notification) bool {
+	return m.Filter != nil && !m.Filter.Match(n)
+}
+
+// notificationKey returns the key of a notification in the model, its thread
+// URL, as thread IDs are only unique per host.
+func notificationKey(n *github.Notification) string {
+	if u := n.GetURL(); u != "" {
+		return u
+	}
+	return n.GetID()
+}
+
+// sameNotification returns true if the notifications are the same thread,
+// used to discard results loaded for a notification no longer displayed.
+func sameNotification(a, b *github.Notification) bool {
+	return a != nil && b != nil && notificationKey(a) == notificationKey(b)
+}
+
+// includes returns true if the values contain s, ignoring case.
+func includes(values []string, s string) bool {
+	for _, v := range values {
+		if equal(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeNotifications merges updates into notifications, replacing those which
+// exist and appending new ones. The keys of new or updated notifications are returned.
+func mergeNotifications(notifications, updates []*github.Notification) (merged []*github.Notification, changed map[string]bool) {
+	changed = make(map[string]bool)
+	merged = append(merged, notifications...)
+
+	for _, u := range updates {
+		i := getNotificationIndex(merged, notificationKey(u))
+
+		// new
+		if i == -1 {
+			merged = append(merged, u)
+			changed[notificationKey(u)] = true
+			continue
+		}
+
+		// updated
+		if u.GetUpdatedAt().After(merged[i].GetUpdatedAt()) {
+			changed[notificationKey(u)] = true
+		}
+		merged[i] = u
+	}
+
+	return
+}
+
+// isPullRequest returns true if the notification is for a pull request.
+func isPullRequest(n *github.Notification) bool {
+	return n.GetSubject().GetType() == "PullRequest"
+}
+
+// reviewState is a reviewer's latest review state.
+type reviewState struct {
+	Login string
+	State string
+}
+
+// reviewStates returns the latest review state of each reviewer, where
+// approvals and change requests take precedence over comments, and
+// requested reviewers are "PENDING".
+func reviewStates(pr *github.PullRequest, reviews []*github.PullRequestReview) (states []reviewState) {
+	index := make(map[string]int)
+
+	set := func(login, state string) {
+		i, ok := index[login]
+		if !ok {
+			index[login] = len(states)
+			states = append(states, reviewState{login, state})
+			return
+		}
+		states[i].State = state
+	}
+
+	for _, r := range reviews {
+		login := r.GetUser().GetLogin()
+		switch state := r.GetState(); state {
+		case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+			set(login, state)
+		case "COMMENTED":
+			if _, ok := index[login]; !ok {
+				set(login, state)
+			}
+		}
+	}
+
+	for _, u := range pr.RequestedReviewers {
+		set(u.GetLogin(), "PENDING")
+	}
+
+	for _, t := range pr.RequestedTeams {
+		set(t.GetSlug(), "PENDING")
+	}
+
+	return
+}
+
+// checkCounts returns the number of passed, failed, and pending
+// check runs and commit statuses.
+func checkCounts(runs []*github.CheckRun, status *github.CombinedStatus) (passed, failed, pending int) {
+	for _, r := range runs {
+		if r.GetStatus() != "completed" {
+			pending++
+			continue
+		}
+
+		switch r.GetConclusion() {
+		case "success", "neutral", "skipped":
+			passed++
+		default:
+			failed++
+		}
+	}
+
+	if status == nil {
+		return
+	}
+
+	for _, s := range status.Statuses {
+		switch s.GetState() {
+		case "success":
+			passed++
+		case "pending":
+			pending++
+		default:
+			failed++
+		}
+	}
+
+	return
+}
+
+// subjectIssue returns an issue with the number of the notification's
+// subject, or nil when the subject is not an issue or pull request.
+func subjectIssue(n *github.Notification) *github.Issue {
+	switch n.GetSubject().GetType() {
+	case "Issue", "PullRequest":
+	default:
+		return nil
+	}
+
+	number, err := strconv.Atoi(path.Base(n.GetSubject().GetURL()))
+	if err != nil {
+		return nil
+	}
+
+	return &github.Issue{
+		Number: &number,
+	}
+}
+
+// visibleNotifications returns the notifications matching the active view and search.
+func visibleNotifications(m Model) []*github.Notification {
+	notifications := shownNotifications(m)
+	if m.ActiveView < len(m.Views) {
+		notifications = filterNotifications(notifications, m.Views[m.ActiveView].Query)
+	}
+	return filterNotifications(notifications, m.SearchInput.Value)
+}
+
 // ownerRepo returns the owner and repo.
 func ownerRepo(n *github.Notification) (owner, repo string) {
 	repository := n.GetRepository()
@@ -40,3 +241,20 @@ func max(a, b int) int {
 	}
 	return b
 }
+
+// olderComments returns the number of comments made before the notification was
+// last read, which are collapsed when enabled and not shown explicitly.
+func olderComments(m Model, collapse bool) int {
+	if !collapse || m.ShowOlderComments || m.Notification.LastReadAt == nil {
+		return 0
+	}
+
+	read := m.Notification.GetLastReadAt()
+	for i, c := range m.Comments {
+		if !c.GetCreatedAt().Before(read) {
+			return i
+		}
+	}
+
+	return len(m.Comments)
+}
@@ -5,14 +5,15 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"sort"
 	"strings"
+	"time"
 
 	"github.com/kr/text"
 	"github.com/tj/go-tea/input"
 
 	"github.com/aybabtme/rgbterm"
 	"github.com/dustin/go-humanize"
+	"github.com/google/go-github/v28/github"
 	"github.com/kyokomi/emoji"
 	"github.com/tj/go-css/csshex"
 	"github.com/tj/go-tea"
@@ -62,6 +63,18 @@ func View(ctx context.Context, model tea.Model) string {
 		return viewLabels(ctx, m)
 	case PagePriorities:
 		return viewPriorities(ctx, m)
+	case PageErrors:
+		return viewErrors(ctx, m)
+	case PageReview:
+		return viewReview(ctx, m)
+	case PageDiff:
+		return viewDiff(ctx, m)
+	case PageClose:
+		return viewClose(ctx, m)
+	case PageLock:
+		return viewLock(ctx, m)
+	case PageAssignees:
+		return viewAssignees(ctx, m)
 	default:
 		panic("unhandled page")
 	}
@@ -73,100 +86,194 @@ func viewNotifications(ctx context.Context, m Model) string {
 
 	// loading
 	if m.Loading {
+		if m.LoadingPage > 1 {
+			return centered(m, fmt.Sprintf("Loading page %d (%d notifications)", m.LoadingPage, len(m.PendingNotifications)))
+		}
 		return loading(m)
 	}
 
 	// no notifications
-	if len(m.Notifications) == 0 {
+	if len(shownNotifications(m)) == 0 {
+		if n := countHidden(m); n > 0 {
+			return centered(m, fmt.Sprintf("Looks like you're all done 😊 (%d hidden, press H to show)", n))
+		}
 		return centered(m, "Looks like you're all done 😊")
 	}
 
 	// padding
 	defer padding(w)()
 
+	// header lines above the list
+	var header int
+
+	// views
+	if len(m.Views) > 1 {
+		fmt.Fprintf(w, "  %s\r\n\r\n", viewTabs(m))
+		header += 2
+	}
+
 	// search focused
 	if m.Searching {
-		fmt.Fprintf(w, "  Searching: %s\r\n\r\n", input.View(m.SearchInput))
+		fmt.Fprintf(w, "  Searching: %s%s\r\n\r\n", input.View(m.SearchInput), queryError(m.SearchInput.Value))
+		header += 2
 	}
 
 	// search blurred
 	if !m.Searching && m.SearchInput.Value != "" {
-		fmt.Fprintf(w, "  Searching: %s\r\n\r\n", m.SearchInput.Value)
+		fmt.Fprintf(w, "  Searching: %s%s\r\n\r\n", m.SearchInput.Value, queryError(m.SearchInput.Value))
+		header += 2
 	}
 
-	// sort by updated time asc
-	sort.Slice(m.Notifications, func(i, j int) bool {
-		a := m.Notifications[i]
-		b := m.Notifications[j]
-		return a.GetUpdatedAt().After(b.GetUpdatedAt())
-	})
-
 	// filter
-	filtered := filterNotifications(m.Notifications, m.SearchInput.Value)
+	items := listItems(m, visibleNotifications(m))
 
 	// notifications
-	for i, n := range filtered {
-		// title
-		if m.Selected == i {
-			fmt.Fprintf(w, "  * %s\r\n", colors.Bold(n.Repository.GetFullName()))
-		} else {
-			fmt.Fprintf(w, "    %s\r\n", colors.Bold(n.Repository.GetFullName()))
-		}
+	for i, item := range items {
+		// repository header
+		if item.Header {
+			arrow := "▾"
+			if m.Collapsed[item.Repo] {
+				arrow = "▸"
+			}
 
-		// marking as read
-		if m.MarkingAsRead && m.Selected == i {
-			fmt.Fprintf(w, "    \033[32mMarking as read.\033[0m\r\n\r\n\r\n")
+			title := fmt.Sprintf("%s %s %s", arrow, colors.Bold(item.Repo), colors.Gray(fmt.Sprintf("(%d)", len(item.Notifications))))
+			if m.Selected == i {
+				fmt.Fprintf(w, "  * %s\r\n", title)
+			} else {
+				fmt.Fprintf(w, "    %s\r\n", title)
+			}
+			fmt.Fprintf(w, "\r\n")
 			continue
 		}
 
-		// unsubscribing
-		if m.Unsubscribing && m.Selected == i {
-			fmt.Fprintf(w, "    \033[32mUnsubscribing.\033[0m\r\n\r\n\r\n")
-			continue
+		n := item.Notification()
+
+		// grouped notifications are indented beneath
+		// the header, which displays the repository
+		var indent string
+		title := colors.Bold(n.Repository.GetFullName())
+		if item.Grouped {
+			indent = "  "
+			title = colors.Bold(n.Subject.GetTitle())
+		}
+		if account := accountBadge(ctx, n); account != "" {
+			title = account + " " + title
+		}
+		if m.Checked[notificationKey(n)] {
+			title = colors.Purple("■") + " " + title
+		}
+		if m.Highlighted[notificationKey(n)] {
+			title += " " + colors.Yellow("new")
+		}
+		if isHidden(m, n) {
+			title += " " + colors.Gray("hidden")
 		}
 
-		// unwatching
-		if m.Unwatching && m.Selected == i {
-			fmt.Fprintf(w, "    \033[32mUnwatching.\033[0m\r\n\r\n\r\n")
-			continue
+		if m.Selected == i {
+			fmt.Fprintf(w, "  * %s%s\r\n", indent, title)
+		} else {
+			fmt.Fprintf(w, "    %s%s\r\n", indent, title)
 		}
 
 		// subject
-		fmt.Fprintf(w, "    %s\r\n", n.Subject.GetTitle())
+		if !item.Grouped {
+			fmt.Fprintf(w, "    %s\r\n", n.Subject.GetTitle())
+		}
 
 		// updated time
-		fmt.Fprintf(w, "    Updated %s (%s)\r\n", humanize.Time(n.GetUpdatedAt()), n.GetReason())
+		fmt.Fprintf(w, "    %sUpdated %s (%s)\r\n", indent, humanize.Time(n.GetUpdatedAt()), n.GetReason())
 		fmt.Fprintf(w, "\r\n")
 	}
 	fmt.Fprintf(w, "\r\n")
 
 	// viewport
 	var offset int
-	if m.Searching || m.SearchInput.Value != "" {
-		offset = 3
+	if header > 0 {
+		offset = header + 1
 	}
 	s := viewport(w.String(), m.NotificationsScrollY, m.Height, offset)
 
 	// menu
-	if m.Searching {
+	switch {
+	case m.Searching:
 		s = menu(s, m,
 			shortcut.Key{"Esc", "Abort"},
 			shortcut.Key{"Enter", "Save"})
-	} else {
+	case len(m.Checked) > 0:
+		s = menu(s, m,
+			shortcut.Key{"Esc", "Clear"},
+			shortcut.Key{"Space", "Select"},
+			shortcut.Key{"a", "Select all"},
+			shortcut.Key{"A", "Select repo"},
+			shortcut.Key{"r", fmt.Sprintf("Mark %d read", len(m.Checked))},
+			shortcut.Key{"u", "Unsubscribe"},
+			shortcut.Key{"U", "Unwatch"},
+			shortcut.Key{"l", "Labels"},
+			shortcut.Key{"p", "Priority"})
+	default:
 		s = menu(s, m,
 			shortcut.Key{"q", "Quit"},
 			shortcut.Key{"→", "View"},
 			shortcut.Key{"↑↓", "Scroll"},
+			shortcut.Key{"Space", "Select"},
+			shortcut.Key{"a", "Select all"},
+			shortcut.Key{"A", "Select repo"},
 			shortcut.Key{"r", "Mark read"},
 			shortcut.Key{"u", "Unsubscribe"},
 			shortcut.Key{"U", "Unwatch"},
+			shortcut.Key{"z", "Undo"},
 			shortcut.Key{"R", "Refresh"},
-			shortcut.Key{"/", "Search"})
+			shortcut.Key{"/", "Search"},
+			shortcut.Key{"g", "Group"},
+			shortcut.Key{"s", "Sort: " + string(m.Sort)},
+			shortcut.Key{"H", "Hidden"},
+			shortcut.Key{"Tab", "Views"},
+			shortcut.Key{"e", "Errors"})
 	}
 
 	return s
 }
 
+// accountBadge returns the notification's account badge when there are multiple accounts.
+func accountBadge(ctx context.Context, n *github.Notification) string {
+	clients, ok := ClientsFromContext(ctx)
+	if !ok || len(clients.Names()) < 2 {
+		return ""
+	}
+
+	name, ok := clients.Account(n)
+	if !ok {
+		return ""
+	}
+
+	return colors.Cyan("[" + name + "]")
+}
+
+// viewTabs returns the saved view tabs with their notification counts.
+func viewTabs(m Model) string {
+	var tabs []string
+	for i, v := range m.Views {
+		count := len(filterNotifications(shownNotifications(m), v.Query))
+		tab := fmt.Sprintf("%d %s (%d)", i+1, v.Name, count)
+		if i == m.ActiveView {
+			tab = colors.Bold(tab)
+		} else {
+			tab = colors.Gray(tab)
+		}
+		tabs = append(tabs, tab)
+	}
+	return strings.Join(tabs, "   ")
+}
+
+// queryError returns the search query error, if any, explaining
+// why the query is matched as free text instead.
+func queryError(s string) string {
+	if _, err := ParseQuery(s); err != nil {
+		return "  " + colors.Gray(err.Error())
+	}
+	return ""
+}
+
 // viewNotification page.
 func viewNotification(ctx context.Context, m Model) string {
 	config := MustConfigFromContext(ctx)
@@ -183,12 +290,19 @@ func viewNotification(ctx context.Context, m Model) string {
 	defer padding(w)()
 
 	// header
-	fmt.Fprintf(w, "    %s\r\n", colors.Bold(n.Repository.GetFullName()))
+	title := colors.Bold(n.Repository.GetFullName())
+	if account := accountBadge(ctx, n); account != "" {
+		title = account + " " + title
+	}
+	if m.Offline {
+		title += " " + colors.Yellow("(offline, cached)")
+	}
+	fmt.Fprintf(w, "    %s\r\n", title)
 	fmt.Fprintf(w, "    %s\r\n", n.Subject.GetTitle())
 	if issue == nil {
 		fmt.Fprintf(w, "\r\n")
 	} else {
-		fmt.Fprintf(w, "    #%d opened %s by @%s\r\n", issue.GetNumber(), humanize.Time(issue.GetCreatedAt()), issue.GetUser().GetLogin())
+		fmt.Fprintf(w, "    #%d opened %s by @%s%s\r\n", issue.GetNumber(), humanize.Time(issue.GetCreatedAt()), issue.GetUser().GetLogin(), viewIssueState(m))
 	}
 
 	// pending
@@ -197,18 +311,6 @@ func viewNotification(ctx context.Context, m Model) string {
 		fmt.Fprintf(w, "\r\n%s\r\n\r\n", hr())
 		fmt.Fprintf(w, "    Loading\r\n")
 		return w.String()
-	case m.MarkingAsRead:
-		fmt.Fprintf(w, "\r\n%s\r\n\r\n", hr())
-		fmt.Fprintf(w, "    Marking as read\r\n")
-		return w.String()
-	case m.Unsubscribing:
-		fmt.Fprintf(w, "\r\n%s\r\n\r\n", hr())
-		fmt.Fprintf(w, "    Unsubscribing\r\n")
-		return w.String()
-	case m.Unwatching:
-		fmt.Fprintf(w, "\r\n%s\r\n\r\n", hr())
-		fmt.Fprintf(w, "    Unwatching\r\n")
-		return w.String()
 	}
 
 	// labels
@@ -229,20 +331,60 @@ func viewNotification(ctx context.Context, m Model) string {
 
 	// body
 	fmt.Fprintf(w, "\r\n%s\r\n\r\n", hr())
+	if isPullRequest(n) {
+		fmt.Fprintf(w, "%s", viewPullRequest(m))
+		fmt.Fprintf(w, "\r\n%s\r\n\r\n", hr())
+	}
 	if body := issue.GetBody(); body == "" {
 		fmt.Fprintf(w, "    No description provided.\r\n")
 	} else {
 		fmt.Fprintf(w, "%s", text.Indent(markdownText(body, theme), "    "))
 	}
 
-	// comments
+	// comments and events, collapsing those before the last read
 	fmt.Fprintf(w, "\r\n")
 	fmt.Fprintf(w, "%s\r\n", hr())
-	for i, c := range comments {
+
+	var since time.Time
+	if config.CollapseComments && !m.ShowOlderComments {
+		since = n.GetLastReadAt()
+	}
+
+	older := olderComments(m, config.CollapseComments)
+	comments = comments[older:]
+
+	var events []*github.Timeline
+	if !m.HideEvents {
+		events = m.Events
+	}
+
+	entries := threadEntries(comments, events, since)
+
+	if older > 0 {
+		fmt.Fprintf(w, "\r\n    %s\r\n\r\n", colors.Gray(fmt.Sprintf("%d older comments, press C to show", older)))
+		if len(entries) > 0 {
+			fmt.Fprintf(w, "%s\r\n", hr())
+		}
+	}
+
+	for i, e := range entries {
+		// consecutive events are displayed together
+		if e.Event != nil {
+			if i == 0 || entries[i-1].Event == nil {
+				fmt.Fprintf(w, "\r\n")
+			}
+			fmt.Fprintf(w, "    %s\r\n", viewEvent(e.Event))
+			if i < len(entries)-1 && entries[i+1].Event == nil {
+				fmt.Fprintf(w, "\r\n%s\r\n", hr())
+			}
+			continue
+		}
+
+		c := e.Comment
 		fmt.Fprintf(w, "\r\n")
 		fmt.Fprintf(w, "    %s %s\r\n\r\n", colors.Bold("@"+c.GetUser().GetLogin()), humanize.Time(c.GetCreatedAt()))
 		fmt.Fprintf(w, "%s", text.Indent(markdownText(c.GetBody(), theme), "    "))
-		if i < len(comments)-1 {
+		if i < len(entries)-1 {
 			fmt.Fprintf(w, "%s\r\n", hr())
 		}
 	}
@@ -256,21 +398,220 @@ func viewNotification(ctx context.Context, m Model) string {
 	s := viewport(w.String(), m.NotificationScrollY, m.Height, offset)
 
 	// menu
-	s = menu(s, m,
-		shortcut.Key{"q", "Quit"},
-		shortcut.Key{"←", "Back"},
-		shortcut.Key{"↑↓", "Scroll"},
-		shortcut.Key{"r", "Mark read"},
-		shortcut.Key{"u", "Unsubscribe"},
-		shortcut.Key{"c", "Comment"},
-		shortcut.Key{"l", "Labels"},
-		shortcut.Key{"p", "Priority"},
+	keys := []shortcut.Key{
+		{"q", "Quit"},
+		{"←", "Back"},
+		{"↑↓", "Scroll"},
+		{"r", "Mark read"},
+		{"u", "Unsubscribe"},
+		{"c", "Comment"},
+		{"l", "Labels"},
+		{"a", "Assignees"},
+		{"p", "Priority"},
+	}
+
+	if m.PullRequest != nil {
+		keys = append(keys,
+			shortcut.Key{"d", "Diff"},
+			shortcut.Key{"v", "Review"})
+	}
+
+	if m.Issue.GetState() == "closed" {
+		keys = append(keys, shortcut.Key{"x", "Reopen"})
+	} else {
+		keys = append(keys, shortcut.Key{"x", "Close"})
+	}
+
+	if m.Issue.GetLocked() {
+		keys = append(keys, shortcut.Key{"L", "Unlock"})
+	} else {
+		keys = append(keys, shortcut.Key{"L", "Lock"})
+	}
+
+	keys = append(keys, shortcut.Key{"t", "Events"})
+
+	if config.CollapseComments {
+		keys = append(keys, shortcut.Key{"C", "Older comments"})
+	}
+
+	keys = append(keys,
+		shortcut.Key{"z", "Undo"},
 		shortcut.Key{"o", "Open"},
+		shortcut.Key{"e", "Errors"},
 		shortcut.Key{"R", "Refresh"})
 
+	return menu(s, m, keys...)
+}
+
+// viewIssueState returns the issue's state and lock for the header, such as
+// " · Closed as not planned · Locked (too heated)", or the change in flight.
+func viewIssueState(m Model) string {
+	if m.PendingState != "" {
+		return " · " + colors.Yellow(m.PendingState)
+	}
+
+	var s string
+	issue := m.Issue
+
+	if issue.GetState() == "closed" {
+		switch m.StateReason {
+		case "completed":
+			s += " · " + colors.Purple("Closed as completed")
+		case "not_planned":
+			s += " · " + colors.Gray("Closed as not planned")
+		case "duplicate":
+			s += " · " + colors.Gray("Closed as duplicate")
+		default:
+			s += " · " + colors.Red("Closed")
+		}
+	}
+
+	if issue.GetLocked() {
+		if reason := issue.GetActiveLockReason(); reason != "" {
+			s += " · " + colors.Yellow(fmt.Sprintf("Locked (%s)", reason))
+		} else {
+			s += " · " + colors.Yellow("Locked")
+		}
+	}
+
 	return s
 }
 
+// viewEvent returns a timeline event as a single line.
+func viewEvent(e *github.Timeline) string {
+	s := fmt.Sprintf("%s %s", eventText(e), humanize.Time(e.GetCreatedAt()))
+	if login := e.GetActor().GetLogin(); login != "" {
+		s = "@" + login + " " + s
+	}
+	return colors.Gray("● " + s)
+}
+
+// viewPullRequest returns the pull request summary.
+func viewPullRequest(m Model) string {
+	w := new(bytes.Buffer)
+	pr := m.PullRequest
+
+	// pending
+	switch {
+	case m.LoadingPullRequest:
+		fmt.Fprintf(w, "    Loading pull request\r\n")
+		return w.String()
+	case pr == nil:
+		fmt.Fprintf(w, "    Pull request unavailable\r\n")
+		return w.String()
+	}
+
+	// state
+	fmt.Fprintf(w, "    %s wants to merge %s into %s\r\n",
+		pullRequestState(pr),
+		colors.Bold(pr.GetHead().GetLabel()),
+		colors.Bold(pr.GetBase().GetLabel()))
+
+	// changes
+	fmt.Fprintf(w, "    %d commits, %d files changed, %s %s\r\n",
+		pr.GetCommits(),
+		pr.GetChangedFiles(),
+		colors.Purple(fmt.Sprintf("+%d", pr.GetAdditions())),
+		colors.Red(fmt.Sprintf("-%d", pr.GetDeletions())))
+
+	// reviews
+	var reviews []string
+	for _, r := range reviewStates(pr, m.Reviews) {
+		reviews = append(reviews, fmt.Sprintf("@%s %s", r.Login, reviewStateText(r.State)))
+	}
+	if len(reviews) == 0 {
+		reviews = append(reviews, "None")
+	}
+	fmt.Fprintf(w, "    Reviews: %s\r\n", strings.Join(reviews, ", "))
+
+	// checks
+	passed, failed, pending := checkCounts(m.CheckRuns, m.Status)
+	if passed+failed+pending == 0 {
+		fmt.Fprintf(w, "    Checks: None\r\n")
+	} else {
+		fmt.Fprintf(w, "    Checks: %s, %s, %s\r\n",
+			colors.Purple(fmt.Sprintf("%d passed", passed)),
+			colors.Red(fmt.Sprintf("%d failed", failed)),
+			colors.Yellow(fmt.Sprintf("%d pending", pending)))
+	}
+
+	return w.String()
+}
+
+// pullRequestState returns the pull request's merge state.
+func pullRequestState(pr *github.PullRequest) string {
+	switch {
+	case pr.GetMerged():
+		return colors.Purple("Merged")
+	case pr.GetState() == "closed":
+		return colors.Red("Closed")
+	case pr.GetDraft():
+		return colors.Gray("Draft")
+	}
+
+	switch pr.GetMergeableState() {
+	case "clean":
+		return colors.Purple("Ready")
+	case "dirty":
+		return colors.Red("Conflicting")
+	case "blocked":
+		return colors.Yellow("Blocked")
+	case "behind":
+		return colors.Yellow("Behind")
+	case "unstable":
+		return colors.Yellow("Unstable")
+	default:
+		return "Open"
+	}
+}
+
+// reviewStateText returns the human-friendly review state.
+func reviewStateText(state string) string {
+	switch state {
+	case "APPROVED":
+		return colors.Purple("approved")
+	case "CHANGES_REQUESTED":
+		return colors.Red("requested changes")
+	case "DISMISSED":
+		return colors.Gray("dismissed")
+	case "PENDING":
+		return colors.Yellow("pending")
+	default:
+		return "commented"
+	}
+}
+
+// viewDiff page.
+func viewDiff(ctx context.Context, m Model) string {
+	// loading
+	if m.LoadingDiff {
+		return loading(m)
+	}
+
+	w := new(bytes.Buffer)
+	n := m.Notification
+
+	// padding
+	defer padding(w)()
+
+	// header
+	fmt.Fprintf(w, "    %s\r\n", colors.Bold(n.Repository.GetFullName()))
+	fmt.Fprintf(w, "    #%d %s\r\n", m.PullRequest.GetNumber(), n.Subject.GetTitle())
+	fmt.Fprintf(w, "\r\n%s\r\n\r\n", hr())
+
+	// diff
+	fmt.Fprintf(w, "%s", m.DiffText)
+
+	// viewport
+	s := viewport(w.String(), m.DiffScrollY, m.Height, 6)
+
+	return menu(s, m,
+		shortcut.Key{"←", "Back"},
+		shortcut.Key{"↑↓", "Scroll"},
+		shortcut.Key{"n/N", "Next/prev file"},
+		shortcut.Key{"]/[", "Next/prev hunk"})
+}
+
 // viewLabels page.
 func viewLabels(ctx context.Context, m Model) string {
 	w := new(bytes.Buffer)
@@ -292,6 +633,27 @@ func viewLabels(ctx context.Context, m Model) string {
 		shortcut.Key{"Enter", "Save"})
 }
 
+// viewAssignees page.
+func viewAssignees(ctx context.Context, m Model) string {
+	w := new(bytes.Buffer)
+
+	// loading
+	if m.Loading {
+		return loading(m)
+	}
+
+	// padding
+	defer padding(w)()
+
+	fmt.Fprintf(w, "  Press space to select assignees:\r\n\r\n")
+	fmt.Fprintf(w, "%s", options.View(m.AssigneeOptions))
+
+	return menu(w.String(), m,
+		shortcut.Key{"Esc", "Abort"},
+		shortcut.Key{"Space", "Toggle"},
+		shortcut.Key{"Enter", "Save"})
+}
+
 // viewPriorities page.
 func viewPriorities(ctx context.Context, m Model) string {
 	w := new(bytes.Buffer)
@@ -323,6 +685,107 @@ func viewComment(ctx context.Context, m Model) string {
 		shortcut.Key{"Enter", "Save"})
 }
 
+// viewErrors page.
+func viewErrors(ctx context.Context, m Model) string {
+	w := new(bytes.Buffer)
+
+	// padding
+	defer padding(w)()
+
+	// no errors
+	if len(m.Errors) == 0 {
+		fmt.Fprintf(w, "  No errors have occurred.\r\n")
+	}
+
+	// errors, most recent first
+	for i := len(m.Errors) - 1; i >= 0; i-- {
+		e := m.Errors[i]
+		fmt.Fprintf(w, "  %s\r\n", colors.Bold(humanize.Time(e.Time)))
+		if n := e.Notification; n != nil {
+			fmt.Fprintf(w, "  %s — %s\r\n", n.Repository.GetFullName(), n.Subject.GetTitle())
+		}
+		fmt.Fprintf(w, "  %s\r\n\r\n", colors.Red(e.Err.Error()))
+	}
+
+	s := viewport(w.String(), m.ErrorsScrollY, m.Height, 1)
+
+	return menu(s, m,
+		shortcut.Key{"←", "Back"},
+		shortcut.Key{"↑↓", "Scroll"})
+}
+
+// viewReview page.
+func viewReview(ctx context.Context, m Model) string {
+	w := new(bytes.Buffer)
+
+	// padding
+	defer padding(w)()
+
+	// event
+	if m.ReviewEvent == "" {
+		fmt.Fprintf(w, "  Select a review type:\r\n\r\n")
+		fmt.Fprintf(w, "%s", option.View(m.ReviewOptions))
+		return menu(w.String(), m,
+			shortcut.Key{"Esc", "Abort"},
+			shortcut.Key{"↑↓", "Select"},
+			shortcut.Key{"Enter", "Next"})
+	}
+
+	// body
+	fmt.Fprintf(w, "  Press enter to submit your review (%s):\r\n\r\n", m.ReviewOptions.Value())
+	fmt.Fprintf(w, "  %s", input.View(m.ReviewInput))
+
+	return menu(w.String(), m,
+		shortcut.Key{"Esc", "Abort"},
+		shortcut.Key{"Enter", "Submit"})
+}
+
+// viewClose page.
+func viewClose(ctx context.Context, m Model) string {
+	w := new(bytes.Buffer)
+
+	// padding
+	defer padding(w)()
+
+	// reason
+	if m.CloseReason == "" && !isPullRequest(m.Notification) {
+		fmt.Fprintf(w, "  Select a reason for closing:\r\n\r\n")
+		fmt.Fprintf(w, "%s", option.View(m.CloseOptions))
+		return menu(w.String(), m,
+			shortcut.Key{"Esc", "Abort"},
+			shortcut.Key{"↑↓", "Select"},
+			shortcut.Key{"Enter", "Next"})
+	}
+
+	// comment
+	if m.CloseReason == "" {
+		fmt.Fprintf(w, "  Press enter to close, with an optional comment:\r\n\r\n")
+	} else {
+		fmt.Fprintf(w, "  Press enter to close as %s, with an optional comment:\r\n\r\n", strings.ToLower(m.CloseOptions.Value()))
+	}
+	fmt.Fprintf(w, "  %s", input.View(m.CloseInput))
+
+	return menu(w.String(), m,
+		shortcut.Key{"Esc", "Abort"},
+		shortcut.Key{"Enter", "Close"})
+}
+
+// viewLock page.
+func viewLock(ctx context.Context, m Model) string {
+	w := new(bytes.Buffer)
+
+	// padding
+	defer padding(w)()
+
+	fmt.Fprintf(w, "  Select a reason for locking the conversation:\r\n\r\n")
+	fmt.Fprintf(w, "%s", option.View(m.LockOptions))
+
+	return menu(w.String(), m,
+		shortcut.Key{"Esc", "Abort"},
+		shortcut.Key{"↑↓", "Select"},
+		shortcut.Key{"Enter", "Lock"})
+}
+
 // loading indicator.
 func loading(m Model) string {
 	if m.Height == 0 {
@@ -349,10 +812,70 @@ func menu(s string, m Model, keys ...shortcut.Key) string {
 		lines = append(lines, "")
 	}
 	lines[len(lines)-2] = strings.Repeat(" ", m.Width)
+	switch {
+	case m.Batch.Running():
+		lines[len(lines)-2] = colors.Yellow(fmt.Sprintf("%s %d/%d", m.Batch.Label, m.Batch.Done, m.Batch.Total))
+	case m.Toast && len(m.Errors) > 0:
+		lines[len(lines)-2] = toast(m, m.Errors[len(m.Errors)-1])
+	case m.Notice != "":
+		lines[len(lines)-2] = colors.Purple(m.Notice)
+	case m.Offline || m.Queued > 0:
+		lines[len(lines)-2] = offline(m)
+	case m.Rate.Limit > 0:
+		lines[len(lines)-2] = rate(m)
+	}
 	lines[len(lines)-1] = shortcut.View(shortcut.Model{keys})
 	return strings.Join(lines, "\r\n")
 }
 
+// rate view of the remaining API quota, right aligned.
+func rate(m Model) string {
+	s := fmt.Sprintf("API %d/%d", m.Rate.Remaining, m.Rate.Limit)
+	if m.Rate.Remaining <= rateLimitThreshold {
+		s += fmt.Sprintf(", resets %s", humanize.Time(m.Rate.Reset.Time))
+	}
+
+	pad := strings.Repeat(" ", max(0, m.Width-len(s)-2))
+	if m.Rate.Remaining <= rateLimitThreshold {
+		return pad + colors.Yellow(s)
+	}
+	return pad + colors.Gray(s)
+}
+
+// offline view of the connectivity and queued changes, right aligned.
+func offline(m Model) string {
+	s := fmt.Sprintf("%d changes queued", m.Queued)
+	if m.Offline {
+		s = "Offline, showing cached data"
+		if m.Queued > 0 {
+			s += fmt.Sprintf(", %d changes queued", m.Queued)
+		}
+	}
+
+	pad := strings.Repeat(" ", max(0, m.Width-len(s)-2))
+	return pad + colors.Yellow(s)
+}
+
+// toast view of an error.
+func toast(m Model, e Failed) string {
+	s := fmt.Sprintf("Error: %s", e.Err)
+	if n := e.Notification; n != nil {
+		s = fmt.Sprintf("Error in %s: %s", n.Repository.GetFullName(), e.Err)
+	}
+	if len(s) > m.Width-2 {
+		s = s[:max(0, m.Width-3)] + "…"
+	}
+	return colors.Red(s)
+}
+
+// codeTheme returns the configured code syntax highlighting theme, or the default.
+func codeTheme(config *Config) termd.SyntaxTheme {
+	if config.Theme.Code != nil {
+		return *config.Theme.Code
+	}
+	return defaultTheme
+}
+
 // viewport returns a view into the lines of text, providing
 // the scroll offset, height of the viewport, and offset
 // which retains N lines behaving like a "sticky" header.